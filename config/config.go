@@ -5,29 +5,417 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // BrowserConfig 浏览器配置
 type BrowserConfig struct {
-	Type      string `json:"type"`      // 浏览器类型：chromium, firefox, webkit
-	Headless  bool   `json:"headless"`  // 是否无头模式
-	SlowMo    int    `json:"slowMo"`    // 慢动作模式，毫秒
-	Maximized bool   `json:"maximized"` // 是否最大化
+	Type      string    `json:"type"`      // 浏览器类型：chromium, firefox, webkit
+	Headless  bool      `json:"headless"`  // 是否无头模式
+	SlowMo    int       `json:"slowMo"`    // 慢动作模式，毫秒
+	Maximized bool      `json:"maximized"` // 是否最大化
+	HAR       HARConfig `json:"har"`       // HAR录制与回放配置
+
+	// 设备与视口模拟，显式配置的字段会覆盖设备描述中的同名设置
+	Name              string          `json:"name"`                // 浏览器条目名称，用于区分同类型浏览器的多个条目；为空时使用type
+	Device            string          `json:"device"`              // 设备描述名称，如 "iPhone 13"、"Pixel 7"
+	Viewport          *ViewportConfig `json:"viewport"`            // 显式视口大小
+	DeviceScaleFactor float64         `json:"device_scale_factor"` // 设备像素比
+	IsMobile          *bool           `json:"is_mobile"`           // 是否模拟移动设备（firefox不支持）
+	HasTouch          *bool           `json:"has_touch"`           // 是否支持触摸
+	UserAgent         string          `json:"user_agent"`          // 用户代理字符串
+	Locale            string          `json:"locale"`              // 浏览器语言，如 zh-CN
+	TimezoneID        string          `json:"timezone_id"`         // 时区，如 Asia/Shanghai
+
+	// 浏览器启动选项
+	Channel           string            `json:"channel"`             // 浏览器发行渠道，如 chrome、msedge（仅chromium支持）
+	ExecutablePath    string            `json:"executable_path"`     // 浏览器可执行文件路径
+	Args              []string          `json:"args"`                // 额外的浏览器启动参数
+	Env               map[string]string `json:"env"`                 // 浏览器进程的环境变量
+	DownloadsPath     string            `json:"downloads_path"`      // 下载文件保存目录
+	Proxy             *ProxyConfig      `json:"proxy"`               // 代理配置
+	IgnoreHTTPSErrors bool              `json:"ignore_https_errors"` // 是否忽略HTTPS证书错误
+	TimeoutMs         int               `json:"timeout_ms"`          // 浏览器启动超时，毫秒；0表示使用Playwright默认值
+
+	Connect *ConnectConfig `json:"connect"` // 连接已有的远程浏览器，配置后不再在本地启动浏览器
+
+	RecycleAfter int `json:"recycle_after"` // 同一个浏览器最多执行的测试数，达到后关闭并重新启动；0表示不回收
+}
+
+// 远程浏览器连接方式
+const (
+	ConnectModeWS  = "ws"  // 通过 BrowserType.Connect 连接 Playwright 服务
+	ConnectModeCDP = "cdp" // 通过 BrowserType.ConnectOverCDP 连接 Chrome DevTools 协议端点（仅chromium）
+)
+
+// ConnectConfig 远程浏览器连接配置
+type ConnectConfig struct {
+	Mode          string            `json:"mode"`           // 连接方式：ws(默认) 或 cdp
+	Endpoint      string            `json:"endpoint"`       // ws://host:port/ 或 http://host:9222
+	Headers       map[string]string `json:"headers"`        // 连接时附加的请求头
+	TimeoutMs     int               `json:"timeout_ms"`     // 连接超时，毫秒；0表示使用Playwright默认值
+	ExposeNetwork string            `json:"expose_network"` // 向远程浏览器暴露的本地网络，如 <loopback>（仅ws）
+	LocalServer   bool              `json:"local_server"`   // 在本地启动 playwright run-server 作为远程浏览器池的替身
+}
+
+// ProxyConfig 代理配置
+type ProxyConfig struct {
+	Server   string `json:"server"`   // 代理服务器，如 http://myproxy.com:3128
+	Bypass   string `json:"bypass"`   // 不走代理的域名，逗号分隔
+	Username string `json:"username"` // 代理用户名
+	Password string `json:"password"` // 代理密码
+}
+
+// 支持的浏览器类型
+const (
+	BrowserChromium = "chromium"
+	BrowserFirefox  = "firefox"
+	BrowserWebKit   = "webkit"
+)
+
+// chromiumChannels chromium支持的发行渠道
+var chromiumChannels = map[string]bool{
+	"chromium":      true,
+	"chrome":        true,
+	"chrome-beta":   true,
+	"chrome-dev":    true,
+	"chrome-canary": true,
+	"msedge":        true,
+	"msedge-beta":   true,
+	"msedge-dev":    true,
+	"msedge-canary": true,
+}
+
+// Validate 按浏览器类型校验启动选项
+func (b BrowserConfig) Validate() error {
+	switch b.Type {
+	case BrowserChromium, BrowserFirefox, BrowserWebKit:
+	default:
+		return fmt.Errorf("不支持的浏览器类型: %q", b.Type)
+	}
+
+	if b.Channel != "" {
+		if b.Type != BrowserChromium {
+			return fmt.Errorf("%s 不支持 channel 配置，仅 chromium 可以指定发行渠道", b.Type)
+		}
+		if !chromiumChannels[b.Channel] {
+			return fmt.Errorf("未知的 chromium 发行渠道: %q", b.Channel)
+		}
+		if b.ExecutablePath != "" {
+			return fmt.Errorf("channel 和 executable_path 不能同时配置")
+		}
+	}
+
+	// 连接远程浏览器时可执行文件在远程机器上，本地无法检查
+	if b.ExecutablePath != "" && b.Connect == nil {
+		if _, err := os.Stat(b.ExecutablePath); err != nil {
+			return fmt.Errorf("浏览器可执行文件不可用: %w", err)
+		}
+	}
+
+	if b.Proxy != nil && b.Proxy.Server == "" {
+		return fmt.Errorf("代理配置缺少 server")
+	}
+
+	if b.TimeoutMs < 0 {
+		return fmt.Errorf("timeout_ms 不能为负数: %d", b.TimeoutMs)
+	}
+
+	if b.RecycleAfter < 0 {
+		return fmt.Errorf("recycle_after 不能为负数: %d", b.RecycleAfter)
+	}
+
+	if b.Connect != nil {
+		switch b.Connect.Mode {
+		case "", ConnectModeWS:
+			if b.Connect.Endpoint == "" && !b.Connect.LocalServer {
+				return fmt.Errorf("ws 连接需要配置 endpoint 或开启 local_server")
+			}
+		case ConnectModeCDP:
+			if b.Type != BrowserChromium {
+				return fmt.Errorf("%s 不支持 CDP 连接，仅 chromium 可以使用 cdp 模式", b.Type)
+			}
+			if b.Connect.Endpoint == "" {
+				return fmt.Errorf("cdp 连接需要配置 endpoint")
+			}
+			if b.Connect.LocalServer {
+				return fmt.Errorf("local_server 只能用于 ws 连接")
+			}
+		default:
+			return fmt.Errorf("未知的连接方式: %q", b.Connect.Mode)
+		}
+		if b.Connect.TimeoutMs < 0 {
+			return fmt.Errorf("connect.timeout_ms 不能为负数: %d", b.Connect.TimeoutMs)
+		}
+	}
+
+	return nil
+}
+
+// ViewportConfig 视口大小
+type ViewportConfig struct {
+	Width  int `json:"width"`  // 宽度，像素
+	Height int `json:"height"` // 高度，像素
+}
+
+// DisplayName 返回浏览器条目名称，用于报告标题和各类产物目录
+func (b BrowserConfig) DisplayName() string {
+	if b.Name != "" {
+		return b.Name
+	}
+	return b.Type
+}
+
+// HAR模式
+const (
+	HARModeOff    = "off"    // 不录制也不回放
+	HARModeRecord = "record" // 录制每个测试的网络请求
+	HARModeReplay = "replay" // 使用之前录制的HAR响应请求
+)
+
+// HARConfig HAR录制与回放配置
+type HARConfig struct {
+	Mode      string `json:"mode"`       // off(默认)、record 或 replay
+	Dir       string `json:"dir"`        // HAR文件目录，默认 ./hars
+	URLFilter string `json:"url_filter"` // 只录制/回放匹配该glob的请求，为空表示全部
+	Strict    bool   `json:"strict"`     // 回放时HAR中不存在的请求直接中止，而不是发往网络
 }
 
 // LoginConfig 登录配置
 type LoginConfig struct {
-	Username        string `json:"username"`         // 用户名
-	Password        string `json:"password"`         // 密码
-	URL             string `json:"url"`              // 登录URL
-	InvalidUsername string `json:"invalid_username"` // 无效用户名
-	InvalidPassword string `json:"invalid_password"` // 无效密码
+	Username        string                   `json:"username"`         // 用户名
+	Password        string                   `json:"password"`         // 密码
+	URL             string                   `json:"url"`              // 登录URL
+	SecureURL       string                   `json:"secure_url"`       // 登录后的安全区域URL
+	InvalidUsername string                   `json:"invalid_username"` // 无效用户名
+	InvalidPassword string                   `json:"invalid_password"` // 无效密码
+	Locale          string                   `json:"locale"`           // 提示信息语言，如 en
+	Messages        map[string]LoginMessages `json:"messages"`         // 各语言对应的预期提示信息
+}
+
+// LoginMessages 登录页面的预期提示信息
+type LoginMessages struct {
+	LoginSuccess    string `json:"login_success"`    // 登录成功提示
+	InvalidUsername string `json:"invalid_username"` // 用户名错误提示
+	InvalidPassword string `json:"invalid_password"` // 密码错误提示
+	LogoutSuccess   string `json:"logout_success"`   // 登出成功提示
+}
+
+// DefaultLocale 默认提示信息语言
+const DefaultLocale = "en"
+
+// DefaultLoginMessages 默认（英文）提示信息
+var DefaultLoginMessages = LoginMessages{
+	LoginSuccess:    "You logged into a secure area!",
+	InvalidUsername: "Your username is invalid!",
+	InvalidPassword: "Your password is invalid!",
+	LogoutSuccess:   "You logged out of the secure area!",
+}
+
+// ExpectedMessages 返回当前语言的预期提示信息，未配置时回退到默认语言
+func (l LoginConfig) ExpectedMessages() LoginMessages {
+	locale := l.Locale
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	if messages, ok := l.Messages[locale]; ok {
+		return messages
+	}
+	if messages, ok := l.Messages[DefaultLocale]; ok {
+		return messages
+	}
+	return DefaultLoginMessages
+}
+
+// AuthConfig 登录状态复用配置
+type AuthConfig struct {
+	StateDir   string `json:"state_dir"`   // 登录状态文件目录
+	TTLSeconds int    `json:"ttl_seconds"` // 登录状态有效期，秒；0表示永不过期
+}
+
+// MockConfig 接口模拟规则
+type MockConfig struct {
+	URL         string            `json:"url"`          // 路由匹配模式（glob），如 **/authenticate
+	Method      string            `json:"method"`       // 请求方法，为空时匹配所有方法
+	Status      int               `json:"status"`       // 响应状态码，默认200
+	Body        string            `json:"body"`         // 静态响应体
+	Fixture     string            `json:"fixture"`      // 响应体fixture文件路径，优先于body
+	ContentType string            `json:"content_type"` // 响应内容类型
+	Headers     map[string]string `json:"headers"`      // 响应头
+	DelayMs     int               `json:"delay_ms"`     // 响应延迟，毫秒
+}
+
+// ConsoleConfig 页面控制台消息采集配置
+type ConsoleConfig struct {
+	Levels          []string `json:"levels"`             // 记录的控制台消息级别，如 error、warning；为空时记录 error 和 warning
+	FailOnPageError bool     `json:"fail_on_page_error"` // 出现未捕获的页面错误时判定测试失败
+}
+
+// NetworkConfig 网络请求检查配置
+type NetworkConfig struct {
+	FailOnHTTPError bool `json:"fail_on_http_error"` // 同源资源返回4xx/5xx时判定测试失败
+}
+
+// 步骤截图模式
+const (
+	ScreenshotModeNever     = "never"      // 不自动截图
+	ScreenshotModeOnFailure = "on-failure" // 步骤失败时截图（默认）
+	ScreenshotModeEveryStep = "every-step" // 每个步骤开始和结束时都截图
+)
+
+// ScreenshotConfig 截图稳定化配置，失败截图和视觉对比截图共用
+type ScreenshotConfig struct {
+	Mode              string   `json:"mode"`               // 步骤截图模式：never、on-failure(默认) 或 every-step
+	Mask              []string `json:"mask"`               // 截图时遮盖的元素选择器，如时间戳
+	MaskColor         string   `json:"mask_color"`         // 遮盖颜色，如 #FF00FF；为空时使用Playwright默认颜色
+	DisableAnimations bool     `json:"disable_animations"` // 禁用CSS动画和过渡
+	HideCaret         bool     `json:"hide_caret"`         // 隐藏文本光标，避免光标闪烁
+	WaitForFonts      bool     `json:"wait_for_fonts"`     // 截图前等待字体加载完成
+	HideScrollbars    bool     `json:"hide_scrollbars"`    // 隐藏滚动条
+}
+
+// Validate 校验截图配置
+func (s ScreenshotConfig) Validate() error {
+	switch s.Mode {
+	case "", ScreenshotModeNever, ScreenshotModeOnFailure, ScreenshotModeEveryStep:
+		return nil
+	default:
+		return fmt.Errorf("未知的截图模式: %q", s.Mode)
+	}
+}
+
+// Region 截图中的矩形区域，单位为像素
+type Region struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// VisualConfig 视觉回归测试配置
+type VisualConfig struct {
+	BaselineDir     string              `json:"baseline_dir"`     // 基线截图目录，按浏览器分子目录
+	OutputDir       string              `json:"output_dir"`       // 实际截图和差异图目录
+	Threshold       float64             `json:"threshold"`        // 单个像素的颜色差异阈值(0-1)，超过视为不同
+	MaxDiffRatio    float64             `json:"max_diff_ratio"`   // 允许的差异像素比例(0-1)
+	UpdateBaselines bool                `json:"update_baselines"` // 使用本次截图覆盖基线
+	Masks           map[string][]Region `json:"masks"`            // 按截图名称配置的忽略区域
+}
+
+// axe-core 违规影响级别，按严重程度从低到高排列
+const (
+	ImpactMinor    = "minor"    // 轻微
+	ImpactModerate = "moderate" // 一般
+	ImpactSerious  = "serious"  // 严重
+	ImpactCritical = "critical" // 致命
+)
+
+// AccessibilityConfig 无障碍检查配置
+type AccessibilityConfig struct {
+	ScriptPath   string   `json:"script_path"`    // 本地 axe-core 脚本路径，运行时不访问网络
+	Tags         []string `json:"tags"`           // 只运行带有这些标签的规则，如 wcag2a、wcag2aa；为空表示全部
+	FailOnImpact string   `json:"fail_on_impact"` // 出现该级别及以上的违规时测试失败，为空表示只记录不失败
+	OutputDir    string   `json:"output_dir"`     // 标注违规元素的截图目录
+}
+
+// Validate 校验无障碍检查配置
+func (a AccessibilityConfig) Validate() error {
+	switch a.FailOnImpact {
+	case "", ImpactMinor, ImpactModerate, ImpactSerious, ImpactCritical:
+		return nil
+	default:
+		return fmt.Errorf("未知的影响级别: %q", a.FailOnImpact)
+	}
+}
+
+// PerformanceBudget 页面性能预算，单位为毫秒，0表示不限制
+type PerformanceBudget struct {
+	TTFBMs             float64 `json:"ttfb_ms"`               // 首字节时间
+	DOMContentLoadedMs float64 `json:"dom_content_loaded_ms"` // DOMContentLoaded 事件结束时间
+	LoadMs             float64 `json:"load_ms"`               // load 事件结束时间
+	FCPMs              float64 `json:"fcp_ms"`                // 首次内容绘制
+	LCPMs              float64 `json:"lcp_ms"`                // 最大内容绘制
+}
+
+// PerformanceConfig 页面性能指标配置
+type PerformanceConfig struct {
+	Budgets map[string]PerformanceBudget `json:"budgets"` // 按URL路径配置的预算，"*" 为其他页面的默认预算
+}
+
+// HistoryConfig 历史结果与趋势配置
+type HistoryConfig struct {
+	Dir     string `json:"dir"`      // 历史结果和趋势页面目录，默认 ./history
+	MaxRuns int    `json:"max_runs"` // 趋势页面展示的最近运行次数，0表示全部
+}
+
+// FlakyConfig 不稳定测试检测配置
+type FlakyConfig struct {
+	Window     int     `json:"window"`     // 参与计算的最近运行次数
+	MinRuns    int     `json:"min_runs"`   // 历史运行次数少于该值时不判定
+	Threshold  float64 `json:"threshold"`  // 结果翻转比例达到该值时判定为不稳定(0-1)
+	Quarantine bool    `json:"quarantine"` // 隔离不稳定测试：照常执行，但失败不影响退出码
+}
+
+// TimeoutConfig 超时配置，单位为毫秒，0表示不限制
+type TimeoutConfig struct {
+	GlobalMs int            `json:"global_ms"` // 整次运行的超时
+	TestMs   int            `json:"test_ms"`   // 单个测试的默认超时
+	StepMs   int            `json:"step_ms"`   // 单个步骤的超时，同时作为Playwright操作的默认超时
+	ExpectMs int            `json:"expect_ms"` // 等待页面元素（如提示信息）出现的超时
+	Tests    map[string]int `json:"tests"`     // 按测试名称覆盖单个测试的超时
+}
+
+// Validate 校验超时配置
+func (t TimeoutConfig) Validate() error {
+	if t.GlobalMs < 0 || t.TestMs < 0 || t.StepMs < 0 || t.ExpectMs < 0 {
+		return fmt.Errorf("超时不能为负数")
+	}
+	for name, ms := range t.Tests {
+		if ms < 0 {
+			return fmt.Errorf("测试 %s 的超时不能为负数", name)
+		}
+	}
+	return nil
+}
+
+// Global 返回整次运行的超时
+func (t TimeoutConfig) Global() time.Duration {
+	return time.Duration(t.GlobalMs) * time.Millisecond
+}
+
+// Test 返回指定测试的超时，优先使用按测试名称配置的值
+func (t TimeoutConfig) Test(name string) time.Duration {
+	if ms, ok := t.Tests[name]; ok {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return time.Duration(t.TestMs) * time.Millisecond
+}
+
+// Step 返回单个步骤的超时
+func (t TimeoutConfig) Step() time.Duration {
+	return time.Duration(t.StepMs) * time.Millisecond
+}
+
+// Expect 返回等待页面元素出现的超时
+func (t TimeoutConfig) Expect() time.Duration {
+	return time.Duration(t.ExpectMs) * time.Millisecond
 }
 
 // Config 应用配置
 type Config struct {
-	Browsers []BrowserConfig `json:"browsers"` // 多浏览器配置
-	Login    LoginConfig     `json:"login"`    // 登录配置
+	Browsers      []BrowserConfig         `json:"browsers"`      // 多浏览器配置
+	Login         LoginConfig             `json:"login"`         // 登录配置
+	Auth          AuthConfig              `json:"auth"`          // 登录状态复用配置
+	Mocks         map[string][]MockConfig `json:"mocks"`         // 按测试名称配置的接口模拟规则
+	Console       ConsoleConfig           `json:"console"`       // 页面控制台消息采集配置
+	Network       NetworkConfig           `json:"network"`       // 网络请求检查配置
+	Screenshot    ScreenshotConfig        `json:"screenshot"`    // 截图稳定化配置
+	Visual        VisualConfig            `json:"visual"`        // 视觉回归测试配置
+	Accessibility AccessibilityConfig     `json:"accessibility"` // 无障碍检查配置
+	Performance   PerformanceConfig       `json:"performance"`   // 页面性能指标配置
+	History       HistoryConfig           `json:"history"`       // 历史结果与趋势配置
+	Flaky         FlakyConfig             `json:"flaky"`         // 不稳定测试检测配置
+	Timeouts      TimeoutConfig           `json:"timeouts"`      // 运行、测试和步骤的超时配置
 }
 
 // DefaultConfig 默认配置
@@ -50,8 +438,58 @@ var DefaultConfig = Config{
 		Username:        "tomsmith",
 		Password:        "SuperSecretPassword!",
 		URL:             "http://the-internet.herokuapp.com/login",
+		SecureURL:       "http://the-internet.herokuapp.com/secure",
 		InvalidUsername: "invaliduser",
 		InvalidPassword: "invalidpass",
+		Locale:          DefaultLocale,
+		Messages: map[string]LoginMessages{
+			DefaultLocale: DefaultLoginMessages,
+		},
+	},
+	Auth: AuthConfig{
+		StateDir:   "./auth",
+		TTLSeconds: 1800,
+	},
+	Console: ConsoleConfig{
+		Levels:          []string{"error", "warning"},
+		FailOnPageError: false,
+	},
+	Screenshot: ScreenshotConfig{
+		Mode:              ScreenshotModeOnFailure,
+		DisableAnimations: true,
+		HideCaret:         true,
+		WaitForFonts:      true,
+		HideScrollbars:    true,
+	},
+	Visual: VisualConfig{
+		BaselineDir:  "./baselines",
+		OutputDir:    "./visual",
+		Threshold:    0.1,
+		MaxDiffRatio: 0.001,
+	},
+	Accessibility: AccessibilityConfig{
+		ScriptPath:   "./third_party/axe-core/axe.min.js",
+		Tags:         []string{"wcag2a", "wcag2aa"},
+		FailOnImpact: ImpactSerious,
+		OutputDir:    "./a11y",
+	},
+	Performance: PerformanceConfig{
+		Budgets: map[string]PerformanceBudget{},
+	},
+	History: HistoryConfig{
+		Dir:     "./history",
+		MaxRuns: 30,
+	},
+	Flaky: FlakyConfig{
+		Window:    10,
+		MinRuns:   3,
+		Threshold: 0.3,
+	},
+	Timeouts: TimeoutConfig{
+		GlobalMs: 1800000,
+		TestMs:   120000,
+		StepMs:   30000,
+		ExpectMs: 5000,
 	},
 }
 
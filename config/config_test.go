@@ -0,0 +1,22 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateExecutablePath(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "chrome")
+
+	local := BrowserConfig{Type: BrowserChromium, ExecutablePath: missing}
+	if err := local.Validate(); err == nil || !strings.Contains(err.Error(), "浏览器可执行文件不可用") {
+		t.Errorf("本地启动时 Validate() = %v，应检查可执行文件", err)
+	}
+
+	// 远程浏览器的可执行文件不在本机
+	remote := BrowserConfig{Type: BrowserChromium, ExecutablePath: missing, Connect: &ConnectConfig{Endpoint: "ws://grid:3000/"}}
+	if err := remote.Validate(); err != nil {
+		t.Errorf("连接远程浏览器时 Validate() = %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+	"github.com/wan/playwright-go-demo/pages"
+	"github.com/wan/playwright-go-demo/utils"
+)
+
+// 夹具名称
+const (
+	fixturePlaywright  = "playwright"           // *playwright.Playwright，由运行器提供
+	fixtureConfig      = "config"               // *config.Config，由运行器提供
+	fixtureSession     = "session"              // *browserSession，由运行器为每个浏览器提供
+	fixturePage        = "page"                 // 测试的页面，由运行器提供
+	fixtureContext     = "context"              // 测试的浏览器上下文，由运行器提供
+	fixtureLoginPage   = "loginPage"            // *pages.LoginPage
+	fixtureAuthState   = "authState"            // 当前浏览器的登录状态文件路径
+	fixtureAuthContext = "authenticatedContext" // 基于登录状态的额外浏览器上下文
+	fixtureAPIClient   = "apiClient"            // playwright.APIRequestContext，以登录页面的站点为基础URL
+	fixtureTempDir     = "tempDir"              // 测试专用的临时目录
+)
+
+// loginFixtures 登录测试可以使用的夹具
+var loginFixtures = utils.NewFixtureRegistry(
+	utils.Fixture{Name: fixtureLoginPage, Scope: utils.ScopeTest, Setup: setupLoginPage},
+	utils.Fixture{Name: fixtureAuthState, Scope: utils.ScopeBrowser, Setup: setupAuthState},
+	utils.Fixture{Name: fixtureAuthContext, Scope: utils.ScopeTest, Setup: setupAuthContext},
+	utils.Fixture{Name: fixtureAPIClient, Scope: utils.ScopeRun, Setup: setupAPIClient},
+	utils.Fixture{Name: fixtureTempDir, Scope: utils.ScopeTest, Setup: setupTempDir},
+)
+
+// setupLoginPage 创建按配置设置好登录URL、预期提示信息和等待超时的登录页面对象
+func setupLoginPage(fixtures *utils.Fixtures) (any, func() error, error) {
+	page, err := utils.UseFixture[playwright.Page](fixtures, fixturePage)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := utils.UseFixture[*config.Config](fixtures, fixtureConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loginPage := pages.NewLoginPage(page)
+	loginPage.SetLoginURL(cfg.Login.URL)
+	loginPage.SetExpectedMessages(cfg.Login.ExpectedMessages())
+	loginPage.SetWaitTimeout(cfg.Timeouts.Expect())
+	return loginPage, nil, nil
+}
+
+// setupAuthState 确保当前浏览器存在有效的登录状态，只在第一个需要登录状态的测试开始前登录
+func setupAuthState(fixtures *utils.Fixtures) (any, func() error, error) {
+	session, err := utils.UseFixture[*browserSession](fixtures, fixtureSession)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := session.browserConfig.DisplayName()
+	browser, err := session.browserPool.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statePath, err := session.authManager.Setup(browser, name, session.contextOptions, loginForAuthState(session.cfg.Login, session.cfg.Timeouts))
+	if err != nil {
+		return nil, nil, fmt.Errorf("准备 %s 浏览器登录状态失败: %w", name, err)
+	}
+	return statePath, nil, nil
+}
+
+// setupAuthContext 基于登录状态创建额外的浏览器上下文，测试结束后关闭
+func setupAuthContext(fixtures *utils.Fixtures) (any, func() error, error) {
+	if _, err := fixtures.Get(fixtureAuthState); err != nil {
+		return nil, nil, err
+	}
+	session, err := utils.UseFixture[*browserSession](fixtures, fixtureSession)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := session.browserConfig.DisplayName()
+	browser, err := session.browserPool.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 额外的上下文不录制视频，避免与测试的主页面视频混在一起
+	options := session.contextOptions
+	options.RecordVideo = nil
+	context, err := session.authManager.NewAuthenticatedContext(browser, name, options, loginForAuthState(session.cfg.Login, session.cfg.Timeouts))
+	if err != nil {
+		return nil, nil, err
+	}
+	return context, func() error { return context.Close() }, nil
+}
+
+// setupAPIClient 创建以登录页面站点为基础URL的接口请求客户端，整次运行共用
+func setupAPIClient(fixtures *utils.Fixtures) (any, func() error, error) {
+	pw, err := utils.UseFixture[*playwright.Playwright](fixtures, fixturePlaywright)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := utils.UseFixture[*config.Config](fixtures, fixtureConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loginURL, err := url.Parse(cfg.Login.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的登录URL: %w", err)
+	}
+	client, err := pw.Request.NewContext(playwright.APIRequestNewContextOptions{
+		BaseURL: playwright.String(loginURL.Scheme + "://" + loginURL.Host),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, func() error { return client.Dispose() }, nil
+}
+
+// setupTempDir 创建测试专用的临时目录，测试结束后删除
+func setupTempDir(fixtures *utils.Fixtures) (any, func() error, error) {
+	dir, err := os.MkdirTemp("", "playwright-test-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	return dir, func() error { return os.RemoveAll(dir) }, nil
+}
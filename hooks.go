@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+	"github.com/wan/playwright-go-demo/utils"
+)
+
+// hookEnv 钩子的运行环境，整次运行级别的钩子没有页面和浏览器上下文
+type hookEnv struct {
+	Ctx     context.Context           // 钩子超时或所属测试超时时取消
+	Browser string                    // 浏览器条目名称，整次运行级别的钩子为空
+	Page    playwright.Page           // BeforeEach/AfterEach 为测试的页面，浏览器级别的钩子为单独的页面
+	Context playwright.BrowserContext // 页面所属的浏览器上下文
+	Login   config.LoginConfig
+	HAR     config.HARConfig // 浏览器条目的HAR配置，整次运行级别的钩子为空
+}
+
+// hook 一个命名的钩子
+type hook struct {
+	Name string
+	Run  func(env *hookEnv) error
+}
+
+// suiteHooks 测试套件的钩子，After* 钩子在测试失败或超时后也会执行
+type suiteHooks struct {
+	BeforeAll     []hook // 整次运行开始前执行一次，失败时跳过所有测试
+	AfterAll      []hook // 整次运行结束后执行一次
+	BeforeBrowser []hook // 每个浏览器执行测试前在单独的浏览器上下文中执行，失败时跳过该浏览器的测试
+	AfterBrowser  []hook // 每个浏览器执行测试后在单独的浏览器上下文中执行
+	BeforeEach    []hook // 每个测试开始前在测试的页面上执行，失败时跳过该测试
+	AfterEach     []hook // 每个测试结束后在测试的页面上执行
+}
+
+// runHooks 依次执行钩子，每个钩子的耗时不超过timeout
+// Before* 钩子失败后不再执行其余钩子，After* 钩子失败后继续执行其余钩子；返回各钩子的结果和第一个错误
+func runHooks(ctx context.Context, stage string, hooks []hook, env *hookEnv, timeout time.Duration) ([]utils.HookResult, error) {
+	var results []utils.HookResult
+	var firstErr error
+	for _, h := range hooks {
+		hookCtx, cancel := utils.WithTimeout(ctx, timeout, fmt.Sprintf("%s 钩子「%s」", stage, h.Name))
+		hookEnv := *env
+		hookEnv.Ctx = hookCtx
+
+		start := time.Now()
+		var hookErr error
+		_, _, err := utils.RunWithContext(hookCtx, func() bool {
+			hookErr = utils.CatchPanic(func() error { return h.Run(&hookEnv) })
+			return hookErr == nil
+		})
+		cancel()
+
+		result := utils.HookResult{
+			Stage:    stage,
+			Name:     h.Name,
+			Status:   "Success",
+			Time:     start,
+			Duration: time.Since(start),
+		}
+		// 超时时钩子可能仍在执行，不能读取hookErr
+		if err == nil && hookErr != nil {
+			result.Status = "Failure"
+			err = hookErr
+		} else if err != nil {
+			result.Status = "Timeout"
+		}
+		if err != nil {
+			result.Message = err.Error()
+			log.Printf("%s %s 钩子「%s」失败: %v", env.Browser, stage, h.Name, err)
+		}
+		results = append(results, result)
+
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s 钩子「%s」失败: %w", stage, h.Name, err)
+		}
+		if err != nil && strings.HasPrefix(stage, "Before") {
+			break
+		}
+	}
+	return results, firstErr
+}
+
+// runBrowserHooks 在单独的、不录制视频的浏览器上下文中执行浏览器级别的钩子，没有钩子时不获取浏览器
+func runBrowserHooks(ctx context.Context, stage string, hooks []hook, getBrowser func() (playwright.Browser, error), options playwright.BrowserNewContextOptions, env hookEnv, timeout time.Duration) ([]utils.HookResult, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	// 无法创建页面时记录为一个失败的钩子，使其出现在报告中
+	start := time.Now()
+	setupFailure := func(err error) ([]utils.HookResult, error) {
+		err = fmt.Errorf("无法为 %s 钩子创建页面: %w", stage, err)
+		return []utils.HookResult{{
+			Stage:    stage,
+			Name:     "创建浏览器上下文",
+			Status:   "Failure",
+			Message:  err.Error(),
+			Time:     start,
+			Duration: time.Since(start),
+		}}, err
+	}
+
+	browser, err := getBrowser()
+	if err != nil {
+		return setupFailure(err)
+	}
+	options.RecordVideo = nil
+	context, err := browser.NewContext(options)
+	if err != nil {
+		return setupFailure(err)
+	}
+	defer context.Close()
+	page, err := context.NewPage()
+	if err != nil {
+		return setupFailure(err)
+	}
+
+	env.Context = context
+	env.Page = page
+	return runHooks(ctx, stage, hooks, &env, timeout)
+}
+
+// skipTests 浏览器无法使用或前置钩子失败时将测试记录为失败，不再执行，也不写入历史结果
+func skipTests(report *utils.ReportManager, tests []testCase, reason string) {
+	for _, tc := range tests {
+		report.StartTest(tc.Name)
+		report.RecordTags(tc.Tags)
+		report.LogSkipped(reason)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+	"github.com/wan/playwright-go-demo/pages"
+	"github.com/wan/playwright-go-demo/utils"
+)
+
+// testEnv 测试用例的运行环境
+type testEnv struct {
+	Ctx           context.Context // 测试、步骤或整次运行超时时取消
+	Fixtures      *utils.Fixtures // 测试级别的夹具，可获取 fixtures.go 中定义的夹具
+	Page          playwright.Page
+	Context       playwright.BrowserContext
+	Login         config.LoginConfig
+	ScreenshotDir string
+	Report        *utils.TestReport // 绑定到当前测试，测试超时后写入的内容被丢弃
+	Visual        *utils.VisualComparer
+	Accessibility *utils.AccessibilityAuditor
+}
+
+// openLoginPage 获取登录页面夹具并导航到登录页面，作为测试的第一个步骤
+func openLoginPage(env *testEnv) (*pages.LoginPage, error) {
+	var loginPage *pages.LoginPage
+	err := env.Report.Step("导航到登录页面", func() error {
+		var err error
+		loginPage, err = utils.UseFixture[*pages.LoginPage](env.Fixtures, fixtureLoginPage)
+		if err != nil {
+			return err
+		}
+		if err := loginPage.Navigate(); err != nil {
+			return fmt.Errorf("导航到登录页面失败: %w", err)
+		}
+		env.Report.SetStepMessage("成功导航到登录页面")
+		return nil
+	})
+	return loginPage, err
+}
+
+// testCase 表示一个测试用例
+type testCase struct {
+	Name            string                   // 测试名称
+	Tags            []string                 // 测试标签，用于 -tags 筛选
+	Authenticated   bool                     // 是否使用已保存的登录状态创建上下文
+	Fixtures        []string                 // 测试需要的夹具，在测试开始前创建
+	OptIn           bool                     // 不在默认测试集中，只在 -tags 表达式写出它的某个标签并且匹配时执行（"!smoke" 不会选中它）
+	Mocks           []config.MockConfig      // 测试期间生效的接口模拟规则
+	AllowHTTPErrors bool                     // 是否允许同源资源返回4xx/5xx（如模拟的接口异常）
+	Run             func(env *testEnv) error // 测试逻辑，返回失败原因
+}
+
+// loginTests 登录相关的测试用例
+var loginTests = []testCase{
+	{
+		Name:     "登录测试",
+		Tags:     []string{"smoke", "negative"},
+		Fixtures: []string{fixtureLoginPage},
+		Run:      runLoginTest,
+	},
+	{
+		Name:          "已登录状态访问安全区域",
+		Tags:          []string{"smoke", "auth"},
+		Authenticated: true,
+		Run:           runAuthenticatedTest,
+	},
+	{
+		Name:     "登录接口异常",
+		Tags:     []string{"negative", "mock"},
+		Fixtures: []string{fixtureLoginPage},
+		Mocks: []config.MockConfig{
+			{
+				URL:         "**/authenticate",
+				Method:      "POST",
+				Status:      500,
+				Body:        serverErrorBody,
+				ContentType: "text/plain",
+			},
+		},
+		AllowHTTPErrors: true,
+		Run:             runLoginServerErrorTest,
+	},
+	{
+		Name:     "登录页面视觉回归",
+		Tags:     []string{"visual", "slow"},
+		Fixtures: []string{fixtureLoginPage},
+		OptIn:    true, // 仓库中还没有提交基线，缺少基线时对比失败
+		Run:      runLoginVisualTest,
+	},
+	{
+		Name:     "登录页面无障碍检查",
+		Tags:     []string{"a11y", "slow"},
+		Fixtures: []string{fixtureLoginPage},
+		Run:      runLoginAccessibilityTest,
+	},
+}
+
+// loginHooks 登录测试的钩子
+var loginHooks = suiteHooks{
+	BeforeBrowser: []hook{
+		{Name: "检查登录页面可访问", Run: checkLoginPageReachable},
+	},
+}
+
+// checkLoginPageReachable 确认登录页面可以访问，避免站点不可用时每个测试都等待到超时
+// HAR回放模式下测试不访问真实站点，不做检查
+func checkLoginPageReachable(env *hookEnv) error {
+	if env.HAR.Mode == config.HARModeReplay {
+		log.Printf("%s 浏览器使用HAR回放，跳过登录页面可访问检查", env.Browser)
+		return nil
+	}
+	response, err := env.Page.Goto(env.Login.URL)
+	if err != nil {
+		return fmt.Errorf("无法打开登录页面: %w", err)
+	}
+	if response != nil && !response.Ok() {
+		return fmt.Errorf("登录页面返回 %d", response.Status())
+	}
+	return nil
+}
+
+// selectTests 返回标签满足筛选表达式的测试用例，表达式没有写出其标签的 OptIn 测试不包括在内
+func selectTests(tests []testCase, filter *utils.TagFilter) []testCase {
+	var selected []testCase
+	for _, tc := range tests {
+		if tc.OptIn && !filter.Names(tc.Tags) {
+			continue
+		}
+		if filter.Match(tc.Tags) {
+			selected = append(selected, tc)
+		}
+	}
+	return selected
+}
+
+// shardTests 返回测试 × 浏览器矩阵中属于当前分片的测试用例
+// 矩阵按浏览器顺序展开，第 browserIndex 个浏览器上的第 i 个测试位于 browserIndex*len(tests)+i
+func shardTests(tests []testCase, browserIndex int, shard utils.Shard) []testCase {
+	var selected []testCase
+	for i, tc := range tests {
+		if shard.Includes(browserIndex*len(tests) + i) {
+			selected = append(selected, tc)
+		}
+	}
+	return selected
+}
+
+// serverErrorBody 模拟登录接口返回的错误响应体
+const serverErrorBody = "Internal Server Error"
+
+// runLoginTest 测试错误用户名、错误密码和正确凭据三种登录场景
+func runLoginTest(env *testEnv) error {
+	loginConfig := env.Login
+
+	loginPage, err := openLoginPage(env)
+	if err != nil {
+		return err
+	}
+
+	scenarios := []struct {
+		name     string
+		username string
+		password string
+		verify   func() (bool, error)
+		message  string
+	}{
+		{
+			name:     "测试错误用户名登录",
+			username: loginConfig.InvalidUsername,
+			password: loginConfig.Password,
+			verify: func() (bool, error) {
+				return loginPage.VerifyLoginFailedWithReason(pages.ReasonInvalidUsername)
+			},
+			message: "成功验证错误用户名登录失败场景",
+		},
+		{
+			name:     "测试错误密码登录",
+			username: loginConfig.Username,
+			password: loginConfig.InvalidPassword,
+			verify: func() (bool, error) {
+				return loginPage.VerifyLoginFailedWithReason(pages.ReasonInvalidPassword)
+			},
+			message: "成功验证错误密码登录失败场景",
+		},
+		{
+			name:     "测试正确凭据登录",
+			username: loginConfig.Username,
+			password: loginConfig.Password,
+			verify:   loginPage.VerifyLoginSuccessMessage,
+			message:  "成功验证正确凭据登录",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		err := env.Report.Step(scenario.name, func() error {
+			if err := env.Report.Step("提交登录表单", func() error {
+				return loginPage.Login(scenario.username, scenario.password)
+			}); err != nil {
+				return err
+			}
+			if err := env.Report.Step("验证登录结果", func() error {
+				return expect(scenario.verify())
+			}); err != nil {
+				return err
+			}
+			env.Report.SetStepMessage(scenario.message)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expect 将页面对象的验证结果转换为错误，验证未通过且没有错误时返回通用的失败说明
+func expect(ok bool, err error) error {
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("页面未显示预期的结果")
+	}
+	return nil
+}
+
+// runAuthenticatedTest 使用已保存的登录状态直接访问安全区域
+func runAuthenticatedTest(env *testEnv) error {
+	securePage := pages.NewSecurePage(env.Page)
+	securePage.SetSecureURL(env.Login.SecureURL)
+
+	return env.Report.Step("使用已保存的登录状态访问安全区域", func() error {
+		if err := securePage.Navigate(); err != nil {
+			return fmt.Errorf("导航到安全区域失败: %w", err)
+		}
+		if err := expect(securePage.VerifyLoggedIn()); err != nil {
+			return fmt.Errorf("验证已登录状态失败: %w", err)
+		}
+		env.Report.SetStepMessage("成功使用已保存的登录状态访问安全区域")
+		return nil
+	})
+}
+
+// runLoginServerErrorTest 模拟登录接口返回500，验证页面不会进入已登录状态
+func runLoginServerErrorTest(env *testEnv) error {
+	page := env.Page
+	loginConfig := env.Login
+
+	loginPage, err := openLoginPage(env)
+	if err != nil {
+		return err
+	}
+
+	return env.Report.Step("登录接口返回500", func() error {
+		if err := loginPage.Login(loginConfig.Username, loginConfig.Password); err != nil {
+			return fmt.Errorf("提交登录表单失败: %w", err)
+		}
+		body, err := page.Locator("body").InnerText()
+		if err != nil {
+			return fmt.Errorf("无法读取页面内容: %w", err)
+		}
+		if !strings.Contains(body, serverErrorBody) {
+			return fmt.Errorf("页面未显示模拟的错误响应，实际内容: %q", body)
+		}
+		if loggedIn, _ := page.IsVisible("a[href=\"/logout\"]"); loggedIn {
+			return fmt.Errorf("登录接口异常时不应进入已登录状态")
+		}
+		env.Report.SetStepMessage("成功验证登录接口异常场景")
+		return nil
+	})
+}
+
+// runLoginVisualTest 将登录页面和登录表单与基线截图对比
+func runLoginVisualTest(env *testEnv) error {
+	page := env.Page
+
+	if _, err := openLoginPage(env); err != nil {
+		return err
+	}
+
+	if err := env.Report.Step("对比登录页面截图", func() error {
+		return verifyVisual(env, func() (*utils.VisualResult, error) {
+			return env.Visual.ComparePage(page, "login-page")
+		})
+	}); err != nil {
+		return err
+	}
+
+	return env.Report.Step("对比登录表单截图", func() error {
+		return verifyVisual(env, func() (*utils.VisualResult, error) {
+			return env.Visual.CompareElement(page, "#login", "login-form")
+		})
+	})
+}
+
+// runLoginAccessibilityTest 使用axe-core检查登录页面的无障碍问题
+func runLoginAccessibilityTest(env *testEnv) error {
+	reportManager := env.Report
+
+	loginPage, err := openLoginPage(env)
+	if err != nil {
+		return err
+	}
+
+	return reportManager.Step("检查登录页面无障碍问题", func() error {
+		result, err := loginPage.CheckAccessibility(env.Accessibility)
+		if err != nil {
+			return fmt.Errorf("无障碍检查失败: %w", err)
+		}
+		reportManager.RecordAccessibility(*result)
+		reportManager.SetStepMessage(result.Message)
+		if !result.Passed {
+			reportManager.AttachScreenshot(result.Screenshot)
+			return fmt.Errorf("登录页面存在无障碍问题: %s", result.Message)
+		}
+		return nil
+	})
+}
+
+// verifyVisual 执行视觉对比并记录到当前步骤，未通过时以差异图作为步骤截图
+func verifyVisual(env *testEnv, compare func() (*utils.VisualResult, error)) error {
+	result, err := compare()
+	if err != nil {
+		return fmt.Errorf("视觉对比失败: %w", err)
+	}
+	env.Report.RecordVisual(*result)
+	if !result.Passed {
+		env.Report.AttachScreenshot(result.Diff)
+		return fmt.Errorf("%s 与基线不一致: %s", result.Name, result.Message)
+	}
+	env.Report.SetStepMessage(fmt.Sprintf("%s: %s", result.Name, result.Message))
+	return nil
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
@@ -14,6 +18,36 @@ import (
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run 执行全部测试并返回退出码：存在未隔离的失败测试时返回1
+func run() int {
+	updateBaselines := flag.Bool("update-baselines", false, "使用本次截图更新视觉回归基线")
+	tagExpression := flag.String("tags", "", "按标签筛选测试，支持 &&、||、! 和括号，如 \"smoke && !slow\"")
+	shardValue := flag.String("shard", "", "只执行测试 × 浏览器矩阵中的第 i 个分片，格式为 i/n")
+	flag.Parse()
+
+	// merge-reports 子命令合并各分片的JSON结果，不执行测试
+	if flag.Arg(0) == "merge-reports" {
+		return mergeReports(flag.Args()[1:])
+	}
+
+	shard, err := utils.ParseShard(*shardValue)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	tagFilter, err := utils.ParseTagFilter(*tagExpression)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	tests := selectTests(loginTests, tagFilter)
+	if len(tests) == 0 {
+		fmt.Printf("没有匹配标签表达式 %q 的测试\n", tagFilter.String())
+		return 0
+	}
+
 	// 清理旧的测试结果
 	if err := utils.CleanupOldTestResults(); err != nil {
 		log.Printf("警告: 清理旧测试结果失败: %v", err)
@@ -25,6 +59,28 @@ func main() {
 	if err != nil {
 		log.Fatalf("加载配置文件失败: %v", err)
 	}
+	if *updateBaselines {
+		cfg.Visual.UpdateBaselines = true
+	}
+	if err := cfg.Screenshot.Validate(); err != nil {
+		log.Fatalf("截图配置无效: %v", err)
+	}
+	if err := cfg.Accessibility.Validate(); err != nil {
+		log.Fatalf("无障碍检查配置无效: %v", err)
+	}
+	if err := cfg.Timeouts.Validate(); err != nil {
+		log.Fatalf("超时配置无效: %v", err)
+	}
+	utils.SetDefaultScreenshotOptions(cfg.Screenshot)
+
+	// 清理本次运行将要写入的旧JSON结果
+	var browserNames []string
+	for _, browserConfig := range cfg.Browsers {
+		browserNames = append(browserNames, browserConfig.DisplayName())
+	}
+	if err := utils.ClearResults(utils.DefaultResultsDir, browserNames, shard); err != nil {
+		log.Printf("警告: 清理旧JSON结果失败: %v", err)
+	}
 
 	// 初始化Playwright
 	pw, err := playwright.Run()
@@ -45,171 +101,610 @@ func main() {
 		os.MkdirAll(videoDir, 0755)
 	}
 
+	// 登录状态管理器，各浏览器登录一次后复用storageState
+	authManager := utils.NewAuthStateManager(cfg.Auth.StateDir, time.Duration(cfg.Auth.TTLSeconds)*time.Second)
+
+	// 浏览器池，每个浏览器配置只启动一次
+	browserPool := utils.NewBrowserPool()
+	defer browserPool.Close()
+
+	// 历史结果存储，每次运行的结果追加写入，用于生成趋势页面
+	historyStore := utils.NewHistoryStore(cfg.History.Dir)
+	trendsPath := filepath.Join(filepath.Dir(historyStore.Path()), "trends.html")
+	runID := time.Now().Format("20060102-150405")
+
+	// 根据本次运行之前的历史结果检测不稳定测试
+	history, err := historyStore.Load()
+	if err != nil {
+		log.Printf("警告: 读取历史结果失败: %v", err)
+	}
+	flakyDetector := utils.NewFlakyDetector(history, cfg.Flaky)
+	failures := 0
+
+	// 整次运行的超时，超时后剩余的测试直接标记为超时
+	runCtx, cancelRun := utils.WithTimeout(context.Background(), cfg.Timeouts.Global(), "整次运行")
+	defer cancelRun()
+
+	// 整次运行级别的夹具在所有浏览器的测试结束后清理
+	runFixtures := utils.NewFixtures(loginFixtures, utils.ScopeRun, nil)
+	runFixtures.Provide(fixturePlaywright, pw)
+	runFixtures.Provide(fixtureConfig, cfg)
+	defer func() {
+		if err := runFixtures.Teardown(); err != nil {
+			log.Printf("警告: %v", err)
+		}
+	}()
+
+	// BeforeAll 钩子失败时跳过所有测试，AfterAll 钩子仍会执行
+	suiteEnv := &hookEnv{Login: cfg.Login}
+	beforeAll, setupErr := runHooks(runCtx, utils.HookBeforeAll, loginHooks.BeforeAll, suiteEnv, cfg.Timeouts.Step())
+	var reports []*utils.ReportManager
+
 	// 遍历所有配置的浏览器，分别执行测试
-	for _, browserConfig := range cfg.Browsers {
+	for browserIndex, browserConfig := range cfg.Browsers {
+		// 分片执行时只运行矩阵中属于当前分片的测试
+		browserTests := shardTests(tests, browserIndex, shard)
+		title := fmt.Sprintf("%s浏览器登录测试", browserConfig.DisplayName())
+		if len(browserTests) == 0 {
+			fmt.Printf("分片 %s 中没有 %s 浏览器的测试，跳过\n", shard, browserConfig.DisplayName())
+			// 仍然写入空的结果文件，合并报告时据此确认所有分片都已执行
+			emptyReport := utils.NewReportManager(title)
+			emptyReport.SetBrowser(browserConfig.DisplayName())
+			emptyReport.SetTagFilter(tagFilter.String())
+			resultsPath := utils.ResultsPath(utils.DefaultResultsDir, browserConfig.DisplayName(), shard.String())
+			if err := emptyReport.WriteResults(resultsPath, shard.String()); err != nil {
+				log.Printf("警告: 保存测试结果失败: %v", err)
+			}
+			continue
+		}
+
 		// 为每个浏览器创建单独的测试报告
-		reportManager := utils.NewReportManager(fmt.Sprintf("%s浏览器登录测试", browserConfig.Type))
+		reportManager := utils.NewReportManager(title)
+		reportManager.SetScreenshotMode(cfg.Screenshot.Mode)
+		reportManager.SetTrendsPage(trendsPath)
+		reportManager.SetBrowser(browserConfig.DisplayName())
+		reportManager.SetFlakyDetector(flakyDetector)
+		reportManager.SetTagFilter(tagFilter.String())
+		reportManager.RecordHooks(beforeAll...)
+		reports = append(reports, reportManager)
 
 		// 执行特定浏览器的测试
-		runTestWithBrowser(pw, browserConfig, cfg.Login, screenshotDir, videoDir, reportManager)
+		if setupErr != nil {
+			skipTests(reportManager, browserTests, fmt.Sprintf("跳过测试: %v", setupErr))
+			continue
+		}
+		runTestWithBrowser(runCtx, pw, browserConfig, cfg, browserTests, loginHooks, runFixtures, screenshotDir, videoDir, browserPool, authManager, reportManager)
+	}
+
+	// AfterAll 钩子在测试失败或超时后也会执行，结果记录到每个浏览器的报告中
+	afterAll, _ := runHooks(context.WithoutCancel(runCtx), utils.HookAfterAll, loginHooks.AfterAll, suiteEnv, cfg.Timeouts.Step())
+
+	var results []utils.ResultFile
+	for _, reportManager := range reports {
+		reportManager.RecordHooks(afterAll...)
+
+		// 生成测试报告
+		reportPath, err := reportManager.GenerateReport()
+		if err != nil {
+			log.Fatalf("生成测试报告失败: %v", err)
+		}
+		fmt.Printf("测试完成，报告已生成: %s\n", reportPath)
+
+		// 保存JSON结果，供 merge-reports 合并各分片的报告
+		resultsPath := utils.ResultsPath(utils.DefaultResultsDir, reportManager.Browser(), shard.String())
+		if err := reportManager.WriteResults(resultsPath, shard.String()); err != nil {
+			log.Printf("警告: 保存测试结果失败: %v", err)
+		}
+
+		results = append(results, reportManager.Result(shard.String()))
+		if err := historyStore.Append(reportManager.HistoryRecords(runID)); err != nil {
+			log.Printf("警告: 保存历史结果失败: %v", err)
+		}
+	}
+
+	// 整次运行级别的钩子在每个浏览器的报告中都有一份，合并后再统计使其只计一次
+	failures += utils.MergeResults("", results).BlockingFailures()
+
+	// 根据历史结果生成趋势页面
+	if err := utils.GenerateTrendsReport(historyStore, trendsPath, cfg.History.MaxRuns); err != nil {
+		log.Printf("警告: 生成趋势页面失败: %v", err)
+	} else {
+		fmt.Printf("趋势页面已生成: %s\n", trendsPath)
+	}
+
+	if failures > 0 {
+		fmt.Printf("%d 个测试失败\n", failures)
+		return 1
+	}
+	return 0
+}
+
+// mergeReports 合并各分片的JSON结果，生成一份HTML报告和JUnit报告；存在未隔离的失败测试时返回1
+func mergeReports(paths []string) int {
+	if len(paths) == 0 {
+		paths = []string{utils.DefaultResultsDir}
+	}
+
+	results, err := utils.LoadResults(paths...)
+	if err != nil {
+		log.Printf("读取测试结果失败: %v", err)
+		return 1
 	}
+	if len(results) == 0 {
+		fmt.Printf("没有找到测试结果文件: %s\n", strings.Join(paths, ", "))
+		return 1
+	}
+	if err := utils.CheckShards(results); err != nil {
+		log.Printf("无法合并测试结果: %v", err)
+		return 1
+	}
+
+	report := utils.MergeResults("登录测试合并报告", results)
+	reportPath, err := report.GenerateReport()
+	if err != nil {
+		log.Printf("生成合并报告失败: %v", err)
+		return 1
+	}
+	fmt.Printf("已合并 %d 个结果文件，报告已生成: %s\n", len(results), reportPath)
+
+	junitPath := filepath.Join(filepath.Dir(reportPath), "junit.xml")
+	if err := report.GenerateJUnit(junitPath); err != nil {
+		log.Printf("生成JUnit报告失败: %v", err)
+		return 1
+	}
+	fmt.Printf("JUnit报告已生成: %s\n", junitPath)
+
+	if failures := report.BlockingFailures(); failures > 0 {
+		fmt.Printf("%d 个测试失败\n", failures)
+		return 1
+	}
+	return 0
 }
 
 // runTestWithBrowser 使用特定浏览器执行测试
-func runTestWithBrowser(pw *playwright.Playwright, browserConfig config.BrowserConfig, loginConfig config.LoginConfig, screenshotDir, videoDir string, reportManager *utils.ReportManager) {
+func runTestWithBrowser(ctx context.Context, pw *playwright.Playwright, browserConfig config.BrowserConfig, cfg *config.Config, tests []testCase, hooks suiteHooks, runFixtures *utils.Fixtures, screenshotDir, videoDir string, browserPool *utils.BrowserPool, authManager *utils.AuthStateManager, reportManager *utils.ReportManager) {
+	// 浏览器无法使用时将测试记录为失败，使其计入报告和退出码
+	skip := func(reason string) {
+		log.Print(reason)
+		skipTests(reportManager, tests, fmt.Sprintf("跳过测试: %s", reason))
+	}
+
+	// 启动浏览器等准备工作中发生panic时，跳过该浏览器的测试，继续下一个浏览器
+	preparing := true
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicErr := utils.NewPanicError(recovered)
+			log.Printf("%s 浏览器发生panic: %v\n%s", browserConfig.DisplayName(), panicErr.Value, panicErr.Stack)
+			if preparing {
+				skip(fmt.Sprintf("%s 浏览器准备时发生panic: %v", browserConfig.DisplayName(), panicErr.Value))
+			}
+		}
+	}()
+
+	// 校验浏览器启动选项
+	if err := browserConfig.Validate(); err != nil {
+		skip(fmt.Sprintf("%s 浏览器配置无效: %v", browserConfig.DisplayName(), err))
+		return
+	}
+
 	// 根据配置选择浏览器类型
 	var browserType playwright.BrowserType
 	switch browserConfig.Type {
-	case "firefox":
+	case config.BrowserFirefox:
 		browserType = pw.Firefox
-	case "webkit":
+	case config.BrowserWebKit:
 		browserType = pw.WebKit
 	default:
 		browserType = pw.Chromium
 	}
 
-	fmt.Printf("开始使用 %s 浏览器执行测试\n", browserConfig.Type)
+	fmt.Printf("开始使用 %s 浏览器执行测试\n", browserConfig.DisplayName())
 
-	// 创建浏览器实例
-	browser, err := browserType.Launch(playwright.BrowserTypeLaunchOptions{
-		Headless: playwright.Bool(browserConfig.Headless),
-		SlowMo:   playwright.Float(float64(browserConfig.SlowMo)),
-	})
-	if err != nil {
-		log.Printf("无法启动 %s 浏览器: %v", browserConfig.Type, err)
-		return
-	}
-	defer browser.Close()
-
-	// 创建上下文
+	// 提前准备上下文选项，设备模拟配置有误时不必启动浏览器
 	contextOptions := playwright.BrowserNewContextOptions{
 		RecordVideo: &playwright.RecordVideo{
-			Dir: filepath.Join(videoDir, browserConfig.Type), // 为每个浏览器创建单独的视频目录
+			Dir: utils.BrowserDir(videoDir, browserConfig.DisplayName()), // 为每个浏览器创建单独的视频目录
 		},
 	}
+	if err := utils.ApplyEmulation(&contextOptions, pw.Devices, browserConfig); err != nil {
+		skip(fmt.Sprintf("%s 浏览器的设备模拟配置无效: %v", browserConfig.DisplayName(), err))
+		return
+	}
+	if browserConfig.IgnoreHTTPSErrors {
+		contextOptions.IgnoreHttpsErrors = playwright.Bool(true)
+	}
 
-	// 如果配置了最大化，设置视口大小为最大
-	if browserConfig.Maximized {
-		// 设置一个足够大的视口大小来模拟最大化
-		contextOptions.Viewport = &playwright.Size{
-			Width:  1920,
-			Height: 1080,
+	// 配置了远程连接时连接已有浏览器，否则在本地启动
+	endpoint := ""
+	if browserConfig.Connect != nil {
+		endpoint = browserConfig.Connect.Endpoint
+		if browserConfig.Connect.LocalServer {
+			server, err := utils.StartLocalServer()
+			if err != nil {
+				skip(fmt.Sprintf("无法为 %s 浏览器启动本地Playwright服务: %v", browserConfig.DisplayName(), err))
+				return
+			}
+			defer server.Stop()
+			endpoint = server.Endpoint
+		}
+	}
+	launch := func() (playwright.Browser, error) {
+		if browserConfig.Connect != nil {
+			return utils.ConnectBrowser(browserType, browserConfig, endpoint)
 		}
+		return browserType.Launch(utils.BuildLaunchOptions(browserConfig))
+	}
+
+	// 浏览器只启动一次，由各测试在其上创建独立的上下文
+	browserPool.Register(browserConfig.DisplayName(), browserConfig.RecycleAfter, launch)
+	defer browserPool.Release(browserConfig.DisplayName())
+	if _, err := browserPool.Get(browserConfig.DisplayName()); err != nil {
+		skip(fmt.Sprintf("无法启动 %s 浏览器: %v", browserConfig.DisplayName(), err))
+		return
 	}
 
 	// 确保浏览器特定的视频目录存在
-	browserVideoDir := filepath.Join(videoDir, browserConfig.Type)
+	browserVideoDir := utils.BrowserDir(videoDir, browserConfig.DisplayName())
 	if _, err := os.Stat(browserVideoDir); os.IsNotExist(err) {
 		os.MkdirAll(browserVideoDir, 0755)
 	}
 
 	// 确保浏览器特定的截图目录存在
-	browserScreenshotDir := filepath.Join(screenshotDir, browserConfig.Type)
+	browserScreenshotDir := utils.BrowserDir(screenshotDir, browserConfig.DisplayName())
 	if _, err := os.Stat(browserScreenshotDir); os.IsNotExist(err) {
 		os.MkdirAll(browserScreenshotDir, 0755)
 	}
 
-	context, err := browser.NewContext(contextOptions)
+	session := &browserSession{
+		ctx:            ctx,
+		cfg:            cfg,
+		hooks:          hooks,
+		browserConfig:  browserConfig,
+		browserPool:    browserPool,
+		contextOptions: contextOptions,
+		authManager:    authManager,
+		screenshotDir:  browserScreenshotDir,
+		report:         reportManager,
+	}
+
+	// 浏览器级别的夹具在该浏览器的测试全部结束后清理
+	session.fixtures = utils.NewFixtures(loginFixtures, utils.ScopeBrowser, runFixtures)
+	session.fixtures.Provide(fixtureSession, session)
+	defer func() {
+		if err := session.fixtures.Teardown(); err != nil {
+			log.Printf("警告: %s 浏览器%v", browserConfig.DisplayName(), err)
+		}
+	}()
+
+	// 浏览器启动耗时单独记录，不计入测试耗时
+	defer func() {
+		reportManager.RecordBrowserLaunches(browserPool.Launches(browserConfig.DisplayName()))
+	}()
+
+	// BeforeBrowser 钩子失败时跳过该浏览器的测试，AfterBrowser 钩子仍会执行
+	// 测试中浏览器可能被回收或重新启动，钩子执行时再从浏览器池获取
+	browserEnv := hookEnv{Browser: browserConfig.DisplayName(), Login: cfg.Login, HAR: browserConfig.HAR}
+	getBrowser := func() (playwright.Browser, error) {
+		return browserPool.Get(browserConfig.DisplayName())
+	}
+	defer func() {
+		results, _ := runBrowserHooks(context.WithoutCancel(ctx), utils.HookAfterBrowser, hooks.AfterBrowser, getBrowser, contextOptions, browserEnv, cfg.Timeouts.Step())
+		reportManager.RecordHooks(results...)
+	}()
+	results, err := runBrowserHooks(ctx, utils.HookBeforeBrowser, hooks.BeforeBrowser, getBrowser, contextOptions, browserEnv, cfg.Timeouts.Step())
+	reportManager.RecordHooks(results...)
+	if err != nil {
+		skipTests(reportManager, tests, fmt.Sprintf("跳过测试: %v", err))
+		return
+	}
+
+	// 依次执行筛选后的测试用例，之后发生的panic已由各测试自行记录
+	preparing = false
+	for _, tc := range tests {
+		session.runTestCase(tc)
+	}
+}
+
+// cancelGracePeriod 测试超时并关闭浏览器上下文后，等待测试代码结束的最长时间
+const cancelGracePeriod = 10 * time.Second
+
+// browserSession 在同一个浏览器上执行测试用例所需的共享状态
+type browserSession struct {
+	ctx            context.Context // 整次运行的context，超时后剩余测试直接标记为超时
+	cfg            *config.Config
+	hooks          suiteHooks
+	browserConfig  config.BrowserConfig
+	browserPool    *utils.BrowserPool
+	contextOptions playwright.BrowserNewContextOptions
+	authManager    *utils.AuthStateManager
+	fixtures       *utils.Fixtures // 浏览器级别的夹具
+	screenshotDir  string
+	report         *utils.ReportManager
+}
+
+// runTestCase 在独立的浏览器上下文中执行单个测试用例
+func (s *browserSession) runTestCase(tc testCase) {
+	browserConfig := s.browserConfig
+	contextOptions := s.contextOptions
+	reportManager := s.report
+	// AfterEach 钩子在测试或整次运行超时后也要执行，使用不会被取消的context
+	teardownCtx := context.WithoutCancel(s.ctx)
+
+	// 运行器自身（如准备登录状态、创建夹具）发生panic时记录到当前测试，继续执行下一个测试
+	started := false
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicErr := utils.NewPanicError(recovered)
+			if !started {
+				reportManager.StartTest(tc.Name)
+				reportManager.RecordTags(tc.Tags)
+			}
+			log.Printf("%s 浏览器执行%s时发生panic: %v\n%s", browserConfig.DisplayName(), tc.Name, panicErr.Value, panicErr.Stack)
+			reportManager.LogPanic(panicErr)
+		}
+	}()
+
+	// 获取浏览器放在测试计时之前，回收或崩溃后重新启动的耗时不计入测试耗时
+	browser, browserErr := s.browserPool.Acquire(browserConfig.DisplayName())
+
+	// 登录状态在第一个需要它的测试开始前准备，登录耗时同样不计入测试耗时
+	// 登录不受测试超时限制，但最多等待到整次运行超时；整次运行已超时时不再登录
+	var authErr error
+	if tc.Authenticated && browserErr == nil {
+		var fixtureErr error
+		_, _, err := utils.RunWithContext(s.ctx, func() bool {
+			_, fixtureErr = s.fixtures.Get(fixtureAuthState)
+			return fixtureErr == nil
+		})
+		if err != nil {
+			authErr = err
+		} else {
+			authErr = fixtureErr
+		}
+	}
+
+	reportManager.StartTest(tc.Name)
+	reportManager.RecordTags(tc.Tags)
+	started = true
+	testStart := time.Now()
+	if s.expired() {
+		return
+	}
+	if browserErr != nil {
+		log.Printf("无法获取 %s 浏览器: %v", browserConfig.DisplayName(), browserErr)
+		reportManager.LogFailure(fmt.Sprintf("无法获取浏览器: %v", browserErr), time.Since(testStart))
+		return
+	}
+
+	// 配置HAR录制或回放，每个测试使用单独的HAR文件
+	harConfig := browserConfig.HAR
+	harPath := utils.HARPath(harConfig, browserConfig.DisplayName(), tc.Name)
+	switch harConfig.Mode {
+	case config.HARModeRecord:
+		if err := utils.EnableHARRecording(&contextOptions, harConfig, harPath); err != nil {
+			reportManager.LogFailure(fmt.Sprintf("无法开启HAR录制: %v", err), time.Since(testStart))
+			return
+		}
+		reportManager.RecordHAR(harConfig.Mode, harPath)
+	case config.HARModeReplay:
+		reportManager.RecordHAR(harConfig.Mode, harPath)
+	}
+
+	// 创建上下文，需要登录状态的测试使用已保存的storageState
+	var context playwright.BrowserContext
+	var err error
+	if tc.Authenticated {
+		if authErr != nil {
+			log.Printf("%v", authErr)
+			reportManager.LogFailure(fmt.Sprintf("登录状态不可用，跳过测试: %v", authErr), time.Since(testStart))
+			return
+		}
+		context, err = s.authManager.NewAuthenticatedContext(browser, browserConfig.DisplayName(), contextOptions, loginForAuthState(s.cfg.Login, s.cfg.Timeouts))
+	} else {
+		context, err = browser.NewContext(contextOptions)
+	}
 	if err != nil {
-		log.Printf("无法创建 %s 浏览器上下文: %v", browserConfig.Type, err)
+		log.Printf("无法创建 %s 浏览器上下文: %v", browserConfig.DisplayName(), err)
+		reportManager.LogFailure(fmt.Sprintf("无法创建浏览器上下文: %v", err), time.Since(testStart))
 		return
 	}
 	defer context.Close()
 
+	// 步骤的超时同时作为Playwright操作的默认超时，避免单个操作一直等待
+	if stepTimeout := s.cfg.Timeouts.Step(); stepTimeout > 0 {
+		context.SetDefaultTimeout(float64(stepTimeout.Milliseconds()))
+		context.SetDefaultNavigationTimeout(float64(stepTimeout.Milliseconds()))
+	}
+
+	// 回放模式下使用之前录制的HAR响应请求
+	if harConfig.Mode == config.HARModeReplay {
+		if err := utils.ReplayHAR(context, harConfig, harPath); err != nil {
+			log.Printf("%s 浏览器HAR回放失败: %v", browserConfig.DisplayName(), err)
+			reportManager.LogFailure(fmt.Sprintf("HAR回放失败: %v", err), time.Since(testStart))
+			return
+		}
+	}
+
+	// 注册接口模拟规则，模拟规则优先于HAR回放
+	// 测试用例自带的规则在前，配置文件中同名测试的规则在后（优先匹配）
+	var mocks []config.MockConfig
+	mocks = append(mocks, tc.Mocks...)
+	mocks = append(mocks, s.cfg.Mocks[tc.Name]...)
+	if len(mocks) > 0 {
+		mockRegistry := utils.NewMockRegistry(mocks)
+		if err := mockRegistry.Attach(context); err != nil {
+			log.Printf("无法注册 %s 浏览器的接口模拟规则: %v", browserConfig.DisplayName(), err)
+			reportManager.LogFailure(fmt.Sprintf("无法注册接口模拟规则: %v", err), time.Since(testStart))
+			return
+		}
+		defer func() {
+			reportManager.RecordMocks(mockRegistry.Usages(), mockRegistry.Unmatched())
+		}()
+	}
+
 	// 创建页面
 	page, err := context.NewPage()
 	if err != nil {
-		log.Printf("无法创建 %s 浏览器页面: %v", browserConfig.Type, err)
+		log.Printf("无法创建 %s 浏览器页面: %v", browserConfig.DisplayName(), err)
+		reportManager.LogFailure(fmt.Sprintf("无法创建浏览器页面: %v", err), time.Since(testStart))
 		return
 	}
-	reportManager.StartTest("登录测试")
 
-	// 执行测试
-	testStart := time.Now()
+	// 记录控制台消息和未捕获的页面错误
+	consoleWatcher := utils.WatchConsole(page, reportManager, s.cfg.Console.Levels)
+	// 记录测试期间的网络请求
+	networkWatcher := utils.WatchNetwork(page)
 
-	try := func() bool {
-		// 创建登录页面对象
-		loginPage := pages.NewLoginPage(page)
-		// 设置登录URL
-		loginPage.SetLoginURL(loginConfig.URL)
+	// 每次导航的 load 事件结束后采集性能指标，记录到正在执行的步骤
+	performanceMonitor, err := utils.WatchPerformance(page, s.cfg.Performance)
+	if err != nil {
+		log.Printf("无法采集 %s 浏览器的性能指标: %v", browserConfig.DisplayName(), err)
+		reportManager.LogFailure(fmt.Sprintf("无法采集性能指标: %v", err), time.Since(testStart))
+		return
+	}
+	reportManager.AttachPerformance(performanceMonitor)
 
-		// 步骤1: 导航到登录页面
-		reportManager.StartStep("导航到登录页面")
-		if err := loginPage.Navigate(); err != nil {
-			// 失败时截图
-			screenshotPath := filepath.Join(browserScreenshotDir, "navigate_failure.png")
-			utils.TakeScreenshot(page, screenshotPath)
-			reportManager.EndStepFailure("导航到登录页面失败", err, screenshotPath)
-			return false
-		}
-		reportManager.EndStepSuccess("成功导航到登录页面")
-
-		// 测试场景1: 使用错误的用户名登录
-		reportManager.StartStep("测试错误用户名登录")
-		if err := loginPage.Login("wrong_username", loginConfig.Password); err != nil {
-			screenshotPath := filepath.Join(browserScreenshotDir, "wrong_username_input_failure.png")
-			utils.TakeScreenshot(page, screenshotPath)
-			reportManager.EndStepFailure("输入错误用户名失败", err, screenshotPath)
-			return false
-		}
-		if failed, err := loginPage.VerifyLoginFailed(); err != nil || !failed {
-			screenshotPath := filepath.Join(browserScreenshotDir, "wrong_username_verify_failure.png")
-			utils.TakeScreenshot(page, screenshotPath)
-			reportManager.EndStepFailure("验证错误用户名失败场景失败", err, screenshotPath)
-			return false
-		}
-		reportManager.EndStepSuccess("成功验证错误用户名登录失败场景")
-
-		// 测试场景2: 使用错误的密码登录
-		reportManager.StartStep("测试错误密码登录")
-		if err := loginPage.Login(loginConfig.Username, "wrong_password"); err != nil {
-			screenshotPath := filepath.Join(browserScreenshotDir, "wrong_password_input_failure.png")
-			utils.TakeScreenshot(page, screenshotPath)
-			reportManager.EndStepFailure("输入错误密码失败", err, screenshotPath)
-			return false
-		}
-		if failed, err := loginPage.VerifyLoginFailed(); err != nil || !failed {
-			screenshotPath := filepath.Join(browserScreenshotDir, "wrong_password_verify_failure.png")
-			utils.TakeScreenshot(page, screenshotPath)
-			reportManager.EndStepFailure("验证错误密码失败场景失败", err, screenshotPath)
-			return false
-		}
-		reportManager.EndStepSuccess("成功验证错误密码登录失败场景")
-
-		// 测试场景3: 使用正确的凭据登录
-		reportManager.StartStep("测试正确凭据登录")
-		if err := loginPage.Login(loginConfig.Username, loginConfig.Password); err != nil {
-			screenshotPath := filepath.Join(browserScreenshotDir, "login_failure.png")
-			utils.TakeScreenshot(page, screenshotPath)
-			reportManager.EndStepFailure("登录失败", err, screenshotPath)
-			return false
+	// 按截图模式在步骤开始、结束或失败时自动截图
+	reportManager.AttachPage(page, s.screenshotDir)
+
+	// 测试超时、任一步骤超时或整次运行超时时取消
+	testCtx, cancel := utils.WithTimeout(s.ctx, s.cfg.Timeouts.Test(tc.Name), fmt.Sprintf("测试「%s」", tc.Name))
+	defer cancel()
+	testCtx = reportManager.WatchSteps(testCtx, s.cfg.Timeouts.Step())
+
+	// 测试级别的夹具按需创建，在 AfterEach 钩子之后按创建的相反顺序清理
+	fixtures := utils.NewFixtures(loginFixtures, utils.ScopeTest, s.fixtures)
+	fixtures.Provide(fixturePage, page)
+	fixtures.Provide(fixtureContext, context)
+	defer func() {
+		if err := fixtures.Teardown(); err != nil {
+			log.Printf("警告: %s %v", tc.Name, err)
 		}
-		if success, err := loginPage.VerifyLoginSuccess(); err != nil || !success {
-			screenshotPath := filepath.Join(browserScreenshotDir, "verification_failure.png")
-			utils.TakeScreenshot(page, screenshotPath)
-			reportManager.EndStepFailure("验证登录失败", err, screenshotPath)
-			return false
+	}()
+
+	// AfterEach 钩子在测试成功、失败或超时后都会执行，且只执行一次；超时时在关闭浏览器上下文之前执行
+	testHookEnv := &hookEnv{Browser: browserConfig.DisplayName(), Page: page, Context: context, Login: s.cfg.Login}
+	afterEachDone := false
+	runAfterEach := func() {
+		if afterEachDone {
+			return
 		}
-		reportManager.EndStepSuccess("成功验证正确凭据登录")
+		afterEachDone = true
+		results, _ := runHooks(teardownCtx, utils.HookAfterEach, s.hooks.AfterEach, testHookEnv, s.cfg.Timeouts.Step())
+		reportManager.RecordHooks(results...)
+	}
+	defer runAfterEach()
 
-		return true
+	// 先创建测试声明的夹具，再执行 BeforeEach 钩子，任一失败时跳过测试
+	if err := fixtures.Resolve(tc.Fixtures...); err != nil {
+		reportManager.LogFailure(fmt.Sprintf("跳过测试: %v", err), time.Since(testStart))
+		return
+	}
+	results, err := runHooks(testCtx, utils.HookBeforeEach, s.hooks.BeforeEach, testHookEnv, s.cfg.Timeouts.Step())
+	reportManager.RecordHooks(results...)
+	if err != nil {
+		reportManager.LogFailure(fmt.Sprintf("跳过测试: %v", err), time.Since(testStart))
+		return
 	}
 
-	success := try()
+	// 执行测试
+	env := &testEnv{
+		Ctx:           testCtx,
+		Fixtures:      fixtures,
+		Page:          page,
+		Context:       context,
+		Login:         s.cfg.Login,
+		ScreenshotDir: s.screenshotDir,
+		Report:        reportManager.ForTest(),
+		Visual:        utils.NewVisualComparer(s.cfg.Visual, browserConfig.DisplayName()),
+		Accessibility: utils.NewAccessibilityAuditor(s.cfg.Accessibility, browserConfig.DisplayName()),
+	}
+	// 测试代码中的panic转换为错误，避免结束整个进程
+	var runErr error
+	success, done, err := utils.RunWithContext(testCtx, func() bool {
+		runErr = utils.CatchPanic(func() error { return tc.Run(env) })
+		return runErr == nil
+	})
 	testDuration := time.Since(testStart)
+	if err != nil {
+		log.Printf("%s 浏览器执行%s时超时: %v", browserConfig.DisplayName(), tc.Name, err)
+		reportManager.LogTimeout(err, testDuration)
+		reportManager.RecordNetwork(networkWatcher.Entries())
+		runAfterEach()
+
+		// 关闭浏览器上下文，使仍在等待页面的Playwright调用立即返回，然后继续下一个测试
+		context.Close()
+		select {
+		case <-done:
+		case <-time.After(cancelGracePeriod):
+			log.Printf("警告: %s 超时后仍未结束，继续执行下一个测试", tc.Name)
+		}
+		return
+	}
+
+	reportManager.RecordNetwork(networkWatcher.Entries())
+
+	var panicErr *utils.PanicError
+	if errors.As(runErr, &panicErr) {
+		log.Printf("%s 浏览器执行%s时发生panic: %v\n%s", browserConfig.DisplayName(), tc.Name, panicErr.Value, panicErr.Stack)
+		reportManager.LogPanic(panicErr)
+		return
+	}
+
+	// 根据配置的策略检查页面错误和同源资源的HTTP错误
+	var policyFailures []string
+	if pageErrors := consoleWatcher.PageErrors(); s.cfg.Console.FailOnPageError && len(pageErrors) > 0 {
+		policyFailures = append(policyFailures, fmt.Sprintf("出现 %d 个未捕获的页面错误", len(pageErrors)))
+	}
+	if s.cfg.Network.FailOnHTTPError && !tc.AllowHTTPErrors {
+		if httpErrors := networkWatcher.SameOriginHTTPErrors(s.cfg.Login.URL); len(httpErrors) > 0 {
+			policyFailures = append(policyFailures, fmt.Sprintf("%d 个同源请求返回4xx/5xx，首个为 %d %s", len(httpErrors), httpErrors[0].Status, httpErrors[0].URL))
+		}
+	}
+	if budgetViolations := performanceMonitor.Violations(); len(budgetViolations) > 0 {
+		policyFailures = append(policyFailures, fmt.Sprintf("%d 项性能指标超出预算，首个为 %s", len(budgetViolations), budgetViolations[0]))
+	}
+	if success && len(policyFailures) > 0 {
+		reportManager.LogFailure(fmt.Sprintf("%s失败: %s", tc.Name, strings.Join(policyFailures, "；")), testDuration)
+		return
+	}
 
 	// 完成测试报告
 	if success {
-		reportManager.LogSuccess("登录测试成功", testDuration)
+		reportManager.LogSuccess(fmt.Sprintf("%s成功", tc.Name), testDuration)
 	} else {
-		reportManager.LogFailure("登录测试失败", testDuration)
+		reportManager.LogFailure(fmt.Sprintf("%s失败: %v", tc.Name, runErr), testDuration)
 	}
+}
 
-	// 生成测试报告
-	reportPath, err := reportManager.GenerateReport()
-	if err != nil {
-		log.Fatalf("生成测试报告失败: %v", err)
+// expired 整次运行已超时时将当前测试标记为超时，返回是否已超时
+func (s *browserSession) expired() bool {
+	if s.ctx.Err() == nil {
+		return false
 	}
+	s.report.LogTimeout(context.Cause(s.ctx), 0)
+	return true
+}
 
-	fmt.Printf("测试完成，报告已生成: %s\n", reportPath)
+// loginForAuthState 返回用于生成登录状态的登录流程
+func loginForAuthState(loginConfig config.LoginConfig, timeouts config.TimeoutConfig) func(page playwright.Page) error {
+	return func(page playwright.Page) error {
+		loginPage := pages.NewLoginPage(page)
+		loginPage.SetLoginURL(loginConfig.URL)
+		loginPage.SetExpectedMessages(loginConfig.ExpectedMessages())
+		loginPage.SetWaitTimeout(timeouts.Expect())
+
+		if err := loginPage.Navigate(); err != nil {
+			return fmt.Errorf("导航到登录页面失败: %w", err)
+		}
+		if err := loginPage.Login(loginConfig.Username, loginConfig.Password); err != nil {
+			return err
+		}
+		if success, err := loginPage.VerifyLoginSuccessMessage(); err != nil {
+			return err
+		} else if !success {
+			return fmt.Errorf("未找到登出按钮")
+		}
+		return nil
+	}
 }
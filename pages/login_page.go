@@ -2,21 +2,56 @@ package pages
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+	"github.com/wan/playwright-go-demo/utils"
 )
 
+// FlashKind 提示信息类型
+type FlashKind string
+
+const (
+	FlashSuccess FlashKind = "success" // 成功提示
+	FlashError   FlashKind = "error"   // 错误提示
+	FlashUnknown FlashKind = "unknown" // 无法识别的提示
+)
+
+// FlashMessage 表示页面顶部的提示信息
+type FlashMessage struct {
+	Kind        FlashKind // 提示类型
+	Text        string    // 提示文本（不含关闭按钮）
+	Dismissable bool      // 是否可以关闭
+}
+
+// LoginFailureReason 登录失败原因
+type LoginFailureReason string
+
+const (
+	ReasonInvalidUsername LoginFailureReason = "invalid_username" // 用户名错误
+	ReasonInvalidPassword LoginFailureReason = "invalid_password" // 密码错误
+)
+
+// defaultWaitTimeout 等待提示信息出现的默认超时（毫秒）
+const defaultWaitTimeout = 5000
+
 // LoginPage 表示登录页面对象
 type LoginPage struct {
-	page     playwright.Page
-	loginURL string
+	page        playwright.Page
+	loginURL    string
+	messages    config.LoginMessages
+	waitTimeout float64 // 等待提示信息出现的超时（毫秒）
 }
 
 // NewLoginPage 创建一个新的登录页面对象
 func NewLoginPage(page playwright.Page) *LoginPage {
 	return &LoginPage{
-		page:     page,
-		loginURL: "http://the-internet.herokuapp.com/login", // 默认URL，将被配置文件中的URL覆盖
+		page:        page,
+		loginURL:    "http://the-internet.herokuapp.com/login", // 默认URL，将被配置文件中的URL覆盖
+		messages:    config.DefaultLoginMessages,               // 默认提示信息，将被配置文件中的提示信息覆盖
+		waitTimeout: defaultWaitTimeout,
 	}
 }
 
@@ -25,6 +60,20 @@ func (l *LoginPage) SetLoginURL(url string) {
 	l.loginURL = url
 }
 
+// SetExpectedMessages 设置预期的提示信息
+func (l *LoginPage) SetExpectedMessages(messages config.LoginMessages) {
+	l.messages = messages
+}
+
+// SetWaitTimeout 设置等待提示信息出现的超时，不大于0时使用默认的5秒
+func (l *LoginPage) SetWaitTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		l.waitTimeout = defaultWaitTimeout
+		return
+	}
+	l.waitTimeout = float64(timeout.Milliseconds())
+}
+
 // Navigate 导航到登录页面
 func (l *LoginPage) Navigate() error {
 	_, err := l.page.Goto(l.loginURL, playwright.PageGotoOptions{
@@ -65,7 +114,7 @@ func (l *LoginPage) VerifyLoginSuccess() (bool, error) {
 	// 等待成功消息出现
 	successLocator := l.page.Locator(".flash.success")
 	if err := successLocator.WaitFor(playwright.LocatorWaitForOptions{
-		Timeout: playwright.Float(5000),
+		Timeout: playwright.Float(l.waitTimeout),
 	}); err != nil {
 		return false, fmt.Errorf("未找到成功消息: %w", err)
 	}
@@ -106,7 +155,7 @@ func (l *LoginPage) VerifyLoginFailed() (bool, error) {
 	// 等待错误消息出现
 	errorLocator := l.page.Locator(".flash.error")
 	if err := errorLocator.WaitFor(playwright.LocatorWaitForOptions{
-		Timeout: playwright.Float(5000),
+		Timeout: playwright.Float(l.waitTimeout),
 	}); err != nil {
 		return false, fmt.Errorf("未找到错误消息: %w", err)
 	}
@@ -119,3 +168,103 @@ func (l *LoginPage) VerifyLoginFailed() (bool, error) {
 
 	return loginButton, nil
 }
+
+// GetFlashMessage 读取并解析当前页面的提示信息
+func (l *LoginPage) GetFlashMessage() (*FlashMessage, error) {
+	flashLocator := l.page.Locator("#flash")
+	if err := flashLocator.WaitFor(playwright.LocatorWaitForOptions{
+		Timeout: playwright.Float(l.waitTimeout),
+	}); err != nil {
+		return nil, fmt.Errorf("未找到提示信息: %w", err)
+	}
+
+	// 根据class判断提示类型
+	class, err := flashLocator.GetAttribute("class")
+	if err != nil {
+		return nil, fmt.Errorf("读取提示信息类型失败: %w", err)
+	}
+	kind := FlashUnknown
+	for _, name := range strings.Fields(class) {
+		switch FlashKind(name) {
+		case FlashSuccess, FlashError:
+			kind = FlashKind(name)
+		}
+	}
+
+	// 关闭按钮的文本“×”也包含在提示信息中，需要去掉
+	closeLocator := flashLocator.Locator("a.close")
+	closeCount, err := closeLocator.Count()
+	if err != nil {
+		return nil, fmt.Errorf("检查关闭按钮失败: %w", err)
+	}
+	text, err := flashLocator.InnerText()
+	if err != nil {
+		return nil, fmt.Errorf("读取提示信息文本失败: %w", err)
+	}
+	if closeCount > 0 {
+		closeText, err := closeLocator.First().InnerText()
+		if err != nil {
+			return nil, fmt.Errorf("读取关闭按钮文本失败: %w", err)
+		}
+		text = strings.Replace(text, closeText, "", 1)
+	}
+
+	return &FlashMessage{
+		Kind:        kind,
+		Text:        strings.TrimSpace(text),
+		Dismissable: closeCount > 0,
+	}, nil
+}
+
+// VerifyFlashMessage 验证提示信息的类型和文本
+func (l *LoginPage) VerifyFlashMessage(kind FlashKind, expectedText string) error {
+	flash, err := l.GetFlashMessage()
+	if err != nil {
+		return err
+	}
+	if flash.Kind != kind {
+		return fmt.Errorf("提示信息类型不符: 期望 %s，实际 %s（%q）", kind, flash.Kind, flash.Text)
+	}
+	if flash.Text != expectedText {
+		return fmt.Errorf("提示信息文本不符: 期望 %q，实际 %q", expectedText, flash.Text)
+	}
+	return nil
+}
+
+// VerifyLoginFailedWithReason 验证登录因指定原因失败
+func (l *LoginPage) VerifyLoginFailedWithReason(reason LoginFailureReason) (bool, error) {
+	var expectedText string
+	switch reason {
+	case ReasonInvalidUsername:
+		expectedText = l.messages.InvalidUsername
+	case ReasonInvalidPassword:
+		expectedText = l.messages.InvalidPassword
+	default:
+		return false, fmt.Errorf("未知的登录失败原因: %s", reason)
+	}
+
+	if err := l.VerifyFlashMessage(FlashError, expectedText); err != nil {
+		return false, err
+	}
+
+	// 检查是否仍在登录页面
+	loginButton, err := l.page.IsVisible("button[type=\"submit\"]")
+	if err != nil {
+		return false, fmt.Errorf("检查登录按钮失败: %w", err)
+	}
+
+	return loginButton, nil
+}
+
+// VerifyLoginSuccessMessage 验证登录成功且提示信息正确
+func (l *LoginPage) VerifyLoginSuccessMessage() (bool, error) {
+	if err := l.VerifyFlashMessage(FlashSuccess, l.messages.LoginSuccess); err != nil {
+		return false, err
+	}
+	return l.VerifyLoginSuccess()
+}
+
+// CheckAccessibility 检查登录页面的无障碍问题
+func (l *LoginPage) CheckAccessibility(auditor *utils.AccessibilityAuditor) (*utils.AccessibilityResult, error) {
+	return auditor.Audit(l.page, "login-page")
+}
@@ -0,0 +1,51 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// SecurePage 表示登录后的安全区域页面对象
+type SecurePage struct {
+	page      playwright.Page
+	secureURL string
+}
+
+// NewSecurePage 创建一个新的安全区域页面对象
+func NewSecurePage(page playwright.Page) *SecurePage {
+	return &SecurePage{
+		page:      page,
+		secureURL: "http://the-internet.herokuapp.com/secure", // 默认URL，将被配置文件中的URL覆盖
+	}
+}
+
+// SetSecureURL 设置安全区域URL
+func (s *SecurePage) SetSecureURL(url string) {
+	s.secureURL = url
+}
+
+// Navigate 导航到安全区域页面
+func (s *SecurePage) Navigate() error {
+	_, err := s.page.Goto(s.secureURL, playwright.PageGotoOptions{
+		WaitUntil: playwright.WaitUntilStateNetworkidle,
+	})
+	return err
+}
+
+// VerifyLoggedIn 验证当前处于已登录状态（未被重定向回登录页面）
+func (s *SecurePage) VerifyLoggedIn() (bool, error) {
+	// 未登录时会被重定向到登录页面
+	if !strings.HasPrefix(s.page.URL(), s.secureURL) {
+		return false, fmt.Errorf("未处于安全区域，当前URL: %s", s.page.URL())
+	}
+
+	// 检查是否存在登出按钮
+	logoutButton, err := s.page.IsVisible("a[href=\"/logout\"]")
+	if err != nil {
+		return false, fmt.Errorf("检查登出按钮失败: %w", err)
+	}
+
+	return logoutButton, nil
+}
@@ -0,0 +1,14 @@
+#!/bin/sh
+# 获取固定版本的 axe-core 脚本及其许可证，放入本目录
+set -eu
+
+VERSION=4.10.2
+DIR=$(cd "$(dirname "$0")" && pwd)
+WORK=$(mktemp -d)
+trap 'rm -rf "$WORK"' EXIT
+
+cd "$WORK"
+npm pack --silent "axe-core@$VERSION" >/dev/null
+tar -xzf "axe-core-$VERSION.tgz" package/axe.min.js package/LICENSE
+mv package/axe.min.js package/LICENSE "$DIR/"
+echo "axe-core $VERSION 已写入 $DIR"
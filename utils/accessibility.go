@@ -0,0 +1,298 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// impactRanks 各影响级别的严重程度，数值越大越严重
+var impactRanks = map[string]int{
+	config.ImpactMinor:    1,
+	config.ImpactModerate: 2,
+	config.ImpactSerious:  3,
+	config.ImpactCritical: 4,
+}
+
+// impactSeverities 影响级别对应的中文严重程度
+var impactSeverities = map[string]string{
+	config.ImpactMinor:    "轻微",
+	config.ImpactModerate: "一般",
+	config.ImpactSerious:  "严重",
+	config.ImpactCritical: "致命",
+}
+
+// AccessibilityNode 违反规则的一个元素
+type AccessibilityNode struct {
+	Selector       string `json:"selector"`       // 元素选择器，位于iframe或shadow DOM中时以 >>> 分隔
+	HTML           string `json:"html"`           // 元素HTML片段
+	FailureSummary string `json:"failureSummary"` // 修复建议
+}
+
+// AccessibilityViolation 一条违反的无障碍规则
+type AccessibilityViolation struct {
+	ID          string              `json:"id"`          // 规则ID，如 color-contrast
+	Impact      string              `json:"impact"`      // axe-core 影响级别
+	Help        string              `json:"help"`        // 规则简述
+	Description string              `json:"description"` // 规则说明
+	HelpURL     string              `json:"helpUrl"`     // 规则文档地址
+	Tags        []string            `json:"tags"`        // 规则标签，如 wcag2aa
+	Nodes       []AccessibilityNode `json:"nodes"`       // 违反规则的元素
+}
+
+// Rank 返回违规的严重程度，数值越大越严重
+func (v AccessibilityViolation) Rank() int {
+	return impactRanks[v.Impact]
+}
+
+// Severity 返回违规的中文严重程度
+func (v AccessibilityViolation) Severity() string {
+	if severity, ok := impactSeverities[v.Impact]; ok {
+		return severity
+	}
+	return "未知"
+}
+
+// AccessibilityResult 一次无障碍检查的结果
+type AccessibilityResult struct {
+	Name         string                   // 检查名称
+	URL          string                   // 检查的页面地址
+	Violations   []AccessibilityViolation // 违反的规则，按严重程度从高到低排列
+	Passes       int                      // 通过的规则数
+	Incomplete   int                      // 需要人工确认的规则数
+	FailOnImpact string                   // 判定失败的影响级别，为空表示只记录
+	Screenshot   string                   // 标注违规元素的截图，没有违规时为空
+	Passed       bool                     // 是否通过
+	Message      string                   // 结果说明
+}
+
+// Blocking 返回达到失败级别的违规
+func (r AccessibilityResult) Blocking() []AccessibilityViolation {
+	if r.FailOnImpact == "" {
+		return nil
+	}
+	var blocking []AccessibilityViolation
+	for _, violation := range r.Violations {
+		if violation.Rank() >= impactRanks[r.FailOnImpact] {
+			blocking = append(blocking, violation)
+		}
+	}
+	return blocking
+}
+
+// axeRunScript 在页面中运行axe-core并以JSON返回需要的字段
+const axeRunScript = `async ({ include, tags }) => {
+	const context = include.length > 0 ? { include: include.map(selector => [selector]) } : document;
+	const options = { resultTypes: ['violations'] };
+	if (tags.length > 0) {
+		options.runOnly = { type: 'tag', values: tags };
+	}
+	const results = await window.axe.run(context, options);
+	const selector = target => target.map(part => Array.isArray(part) ? part.join(' >>> ') : part).join(' >>> ');
+	return JSON.stringify({
+		url: results.url,
+		passes: results.passes.length,
+		incomplete: results.incomplete.length,
+		violations: results.violations.map(violation => ({
+			id: violation.id,
+			impact: violation.impact,
+			help: violation.help,
+			description: violation.description,
+			helpUrl: violation.helpUrl,
+			tags: violation.tags,
+			nodes: violation.nodes.map(node => ({
+				selector: selector(node.target),
+				html: node.html,
+				failureSummary: node.failureSummary,
+			})),
+		})),
+	});
+}`
+
+// highlightScript 为违规元素添加醒目的轮廓，无法在当前文档中查询的选择器会被跳过
+const highlightScript = `(selectors) => {
+	const style = document.createElement('style');
+	style.id = '__a11y_highlight';
+	style.textContent = '[data-a11y-violation] { outline: 3px solid #dc3545 !important; outline-offset: 2px !important; }';
+	document.head.appendChild(style);
+	for (const selector of selectors) {
+		try {
+			document.querySelectorAll(selector).forEach(element => element.setAttribute('data-a11y-violation', ''));
+		} catch (e) {}
+	}
+}`
+
+// clearHighlightScript 移除违规元素的轮廓
+const clearHighlightScript = `() => {
+	document.getElementById('__a11y_highlight')?.remove();
+	document.querySelectorAll('[data-a11y-violation]').forEach(element => element.removeAttribute('data-a11y-violation'));
+}`
+
+// AccessibilityAuditor 使用本地 axe-core 脚本检查页面的无障碍问题
+type AccessibilityAuditor struct {
+	config      config.AccessibilityConfig
+	browserName string
+	script      string // 已读取的 axe-core 脚本
+}
+
+// NewAccessibilityAuditor 创建一个新的无障碍检查器
+func NewAccessibilityAuditor(accessibilityConfig config.AccessibilityConfig, browserName string) *AccessibilityAuditor {
+	if accessibilityConfig.ScriptPath == "" {
+		accessibilityConfig.ScriptPath = "./third_party/axe-core/axe.min.js"
+	}
+	if accessibilityConfig.OutputDir == "" {
+		accessibilityConfig.OutputDir = "./a11y"
+	}
+	return &AccessibilityAuditor{
+		config:      accessibilityConfig,
+		browserName: browserName,
+	}
+}
+
+// Audit 检查当前页面，include为只检查的区域选择器，为空时检查整个页面
+func (a *AccessibilityAuditor) Audit(page playwright.Page, name string, include ...string) (*AccessibilityResult, error) {
+	if err := a.inject(page); err != nil {
+		return nil, err
+	}
+
+	tags := a.config.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	if include == nil {
+		include = []string{}
+	}
+	raw, err := page.Evaluate(axeRunScript, map[string]interface{}{
+		"include": include,
+		"tags":    tags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("运行axe-core失败: %w", err)
+	}
+	encoded, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("axe-core返回了无法识别的结果: %v", raw)
+	}
+
+	var output struct {
+		URL        string                   `json:"url"`
+		Passes     int                      `json:"passes"`
+		Incomplete int                      `json:"incomplete"`
+		Violations []AccessibilityViolation `json:"violations"`
+	}
+	if err := json.Unmarshal([]byte(encoded), &output); err != nil {
+		return nil, fmt.Errorf("解析axe-core结果失败: %w", err)
+	}
+	sort.SliceStable(output.Violations, func(i, j int) bool {
+		return output.Violations[i].Rank() > output.Violations[j].Rank()
+	})
+
+	result := &AccessibilityResult{
+		Name:         name,
+		URL:          output.URL,
+		Violations:   output.Violations,
+		Passes:       output.Passes,
+		Incomplete:   output.Incomplete,
+		FailOnImpact: a.config.FailOnImpact,
+	}
+	blocking := result.Blocking()
+	result.Passed = len(blocking) == 0
+	result.Message = summarizeViolations(result.Violations, len(blocking), a.config.FailOnImpact)
+
+	if len(result.Violations) > 0 {
+		screenshot, err := a.highlightViolations(page, name, result.Violations)
+		if err != nil {
+			return nil, err
+		}
+		result.Screenshot = screenshot
+	}
+
+	return result, nil
+}
+
+// inject 将 axe-core 脚本注入页面，页面中已存在时跳过
+func (a *AccessibilityAuditor) inject(page playwright.Page) error {
+	loaded, err := page.Evaluate("() => typeof window.axe !== 'undefined'")
+	if err != nil {
+		return fmt.Errorf("检查axe-core是否已注入失败: %w", err)
+	}
+	if injected, _ := loaded.(bool); injected {
+		return nil
+	}
+
+	if a.script == "" {
+		content, err := os.ReadFile(a.config.ScriptPath)
+		if err != nil {
+			return fmt.Errorf("无法读取axe-core脚本 %s，请先将 axe.min.js 放入该路径: %w", a.config.ScriptPath, err)
+		}
+		a.script = string(content)
+	}
+
+	if _, err := page.AddScriptTag(playwright.PageAddScriptTagOptions{
+		Content: playwright.String(a.script),
+	}); err != nil {
+		return fmt.Errorf("注入axe-core脚本失败: %w", err)
+	}
+	return nil
+}
+
+// highlightViolations 标注违规元素后截图，返回截图路径
+func (a *AccessibilityAuditor) highlightViolations(page playwright.Page, name string, violations []AccessibilityViolation) (string, error) {
+	selectors := []string{}
+	for _, violation := range violations {
+		for _, node := range violation.Nodes {
+			selectors = append(selectors, node.Selector)
+		}
+	}
+
+	dir := BrowserDir(a.config.OutputDir, a.browserName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("无法创建无障碍检查截图目录: %w", err)
+	}
+	path := filepath.Join(dir, sanitizeFileName(name)+".png")
+
+	if _, err := page.Evaluate(highlightScript, selectors); err != nil {
+		return "", fmt.Errorf("标注违规元素失败: %w", err)
+	}
+	screenshotErr := TakeScreenshot(page, path)
+	if _, err := page.Evaluate(clearHighlightScript); err != nil {
+		return "", fmt.Errorf("移除违规元素标注失败: %w", err)
+	}
+	if screenshotErr != nil {
+		return "", screenshotErr
+	}
+	return path, nil
+}
+
+// summarizeViolations 生成按严重程度统计的结果说明
+func summarizeViolations(violations []AccessibilityViolation, blocking int, failOnImpact string) string {
+	if len(violations) == 0 {
+		return "未发现无障碍问题"
+	}
+
+	counts := map[string]int{}
+	for _, violation := range violations {
+		counts[violation.Severity()]++
+	}
+	var parts []string
+	for _, impact := range []string{config.ImpactCritical, config.ImpactSerious, config.ImpactModerate, config.ImpactMinor} {
+		if count := counts[impactSeverities[impact]]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", impactSeverities[impact], count))
+		}
+	}
+	if count := counts["未知"]; count > 0 {
+		parts = append(parts, fmt.Sprintf("未知 %d", count))
+	}
+
+	message := fmt.Sprintf("发现 %d 条无障碍问题（%s）", len(violations), strings.Join(parts, "，"))
+	if failOnImpact != "" {
+		message += fmt.Sprintf("，其中 %d 条达到失败级别「%s」", blocking, impactSeverities[failOnImpact])
+	}
+	return message
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// AuthStateManager 管理各浏览器的登录状态（storageState）文件
+type AuthStateManager struct {
+	stateDir string
+	ttl      time.Duration
+}
+
+// NewAuthStateManager 创建一个新的登录状态管理器，stateDir为空时使用 ./auth，ttl为0表示状态永不过期
+func NewAuthStateManager(stateDir string, ttl time.Duration) *AuthStateManager {
+	if stateDir == "" {
+		stateDir = "./auth"
+	}
+	return &AuthStateManager{
+		stateDir: stateDir,
+		ttl:      ttl,
+	}
+}
+
+// StatePath 返回指定浏览器的登录状态文件路径
+func (a *AuthStateManager) StatePath(browserName string) string {
+	return filepath.Join(a.stateDir, fmt.Sprintf("%s-state.json", sanitizeFileName(browserName)))
+}
+
+// IsValid 检查登录状态文件是否存在且未过期
+func (a *AuthStateManager) IsValid(browserName string) bool {
+	info, err := os.Stat(a.StatePath(browserName))
+	if err != nil {
+		return false
+	}
+	if a.ttl <= 0 {
+		return true
+	}
+	return time.Since(info.ModTime()) < a.ttl
+}
+
+// Invalidate 删除指定浏览器的登录状态文件
+func (a *AuthStateManager) Invalidate(browserName string) error {
+	if err := os.Remove(a.StatePath(browserName)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除登录状态文件失败: %w", err)
+	}
+	return nil
+}
+
+// Setup 确保指定浏览器存在有效的登录状态，状态缺失或过期时调用login重新登录并保存
+func (a *AuthStateManager) Setup(browser playwright.Browser, browserName string, options playwright.BrowserNewContextOptions, login func(page playwright.Page) error) (string, error) {
+	statePath := a.StatePath(browserName)
+	if a.IsValid(browserName) {
+		fmt.Printf("复用 %s 浏览器的登录状态: %s\n", browserName, statePath)
+		return statePath, nil
+	}
+
+	// 确保状态目录存在
+	if err := os.MkdirAll(a.stateDir, 0755); err != nil {
+		return "", fmt.Errorf("无法创建登录状态目录: %w", err)
+	}
+
+	// 登录过程不需要录制视频和HAR
+	options.RecordVideo = nil
+	options.RecordHarPath = nil
+	context, err := browser.NewContext(options)
+	if err != nil {
+		return "", fmt.Errorf("无法创建登录上下文: %w", err)
+	}
+	defer context.Close()
+
+	page, err := context.NewPage()
+	if err != nil {
+		return "", fmt.Errorf("无法创建登录页面: %w", err)
+	}
+
+	if err := login(page); err != nil {
+		return "", fmt.Errorf("登录失败: %w", err)
+	}
+
+	if _, err := context.StorageState(statePath); err != nil {
+		return "", fmt.Errorf("保存登录状态失败: %w", err)
+	}
+
+	fmt.Printf("已保存 %s 浏览器的登录状态: %s\n", browserName, statePath)
+	return statePath, nil
+}
+
+// NewAuthenticatedContext 基于已保存的登录状态创建浏览器上下文
+// 登录状态在运行过程中过期或被删除时，调用login重新登录并保存，之后的测试继续复用新的状态
+func (a *AuthStateManager) NewAuthenticatedContext(browser playwright.Browser, browserName string, options playwright.BrowserNewContextOptions, login func(page playwright.Page) error) (playwright.BrowserContext, error) {
+	if !a.IsValid(browserName) {
+		fmt.Printf("%s 浏览器的登录状态不存在或已过期，重新登录\n", browserName)
+		if _, err := a.Setup(browser, browserName, options, login); err != nil {
+			return nil, fmt.Errorf("%s 浏览器的登录状态不可用: %w", browserName, err)
+		}
+	}
+
+	options.StorageStatePath = playwright.String(a.StatePath(browserName))
+	context, err := browser.NewContext(options)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建已登录的浏览器上下文: %w", err)
+	}
+
+	return context, nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuthStatePathSanitizesBrowserName(t *testing.T) {
+	manager := NewAuthStateManager("", 0)
+	want := filepath.Join("auth", "chromium_(mobile)_a_b-state.json")
+	if got := manager.StatePath("chromium (mobile) a/b"); got != want {
+		t.Errorf("StatePath() = %q，期望 %q", got, want)
+	}
+}
+
+func TestAuthStateExpires(t *testing.T) {
+	dir := t.TempDir()
+	manager := NewAuthStateManager(dir, time.Minute)
+	if manager.IsValid("chromium") {
+		t.Fatal("状态文件不存在时不应有效")
+	}
+
+	path := manager.StatePath("chromium")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !manager.IsValid("chromium") {
+		t.Error("刚保存的状态应有效")
+	}
+
+	// 修改时间早于有效期时视为过期，运行中的测试需要重新登录
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if manager.IsValid("chromium") {
+		t.Error("超过有效期的状态不应有效")
+	}
+	if !NewAuthStateManager(dir, 0).IsValid("chromium") {
+		t.Error("ttl 为 0 时状态永不过期")
+	}
+
+	if err := manager.Invalidate("chromium"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Invalidate 后状态文件仍然存在: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// BrowserLaunch 记录一次浏览器启动
+type BrowserLaunch struct {
+	Browser  string        // 浏览器条目名称
+	Reason   string        // 启动原因：首次启动、达到复用上限、浏览器崩溃
+	Duration time.Duration // 启动耗时
+	Time     time.Time     // 启动时间
+}
+
+// pooledBrowser 浏览器池中的一个浏览器条目
+type pooledBrowser struct {
+	launch       func() (playwright.Browser, error)
+	recycleAfter int
+	browser      playwright.Browser
+	uses         int
+	disconnected *atomic.Bool // 由浏览器的disconnected事件设置
+	launches     []BrowserLaunch
+}
+
+// BrowserPool 每个浏览器配置只启动一次浏览器，由各测试在其上创建独立的上下文
+type BrowserPool struct {
+	mu      sync.Mutex
+	entries map[string]*pooledBrowser
+}
+
+// NewBrowserPool 创建一个新的浏览器池
+func NewBrowserPool() *BrowserPool {
+	return &BrowserPool{
+		entries: make(map[string]*pooledBrowser),
+	}
+}
+
+// Register 注册浏览器条目，recycleAfter为同一个浏览器最多执行的测试数，0表示不限制
+func (p *BrowserPool) Register(name string, recycleAfter int, launch func() (playwright.Browser, error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries[name] = &pooledBrowser{
+		launch:       launch,
+		recycleAfter: recycleAfter,
+	}
+}
+
+// Get 返回可用的浏览器，不计入测试次数；浏览器未启动或已崩溃时重新启动
+func (p *BrowserPool) Get(name string) (playwright.Browser, error) {
+	return p.get(name, false)
+}
+
+// Acquire 为一个测试获取浏览器，达到复用上限或浏览器崩溃时先重新启动
+func (p *BrowserPool) Acquire(name string) (playwright.Browser, error) {
+	return p.get(name, true)
+}
+
+// get 返回浏览器，必要时重新启动
+func (p *BrowserPool) get(name string, countUse bool) (playwright.Browser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("浏览器池中没有 %s 浏览器", name)
+	}
+
+	reason := ""
+	switch {
+	case entry.browser == nil:
+		reason = "首次启动"
+	case entry.disconnected.Load() || !entry.browser.IsConnected():
+		reason = "浏览器崩溃或断开连接后重新启动"
+	case countUse && entry.recycleAfter > 0 && entry.uses >= entry.recycleAfter:
+		reason = fmt.Sprintf("已执行 %d 个测试，回收后重新启动", entry.uses)
+	}
+
+	if reason != "" {
+		if entry.browser != nil {
+			entry.browser.Close()
+			entry.browser = nil
+		}
+
+		start := time.Now()
+		browser, err := entry.launch()
+		if err != nil {
+			return nil, err
+		}
+		launch := BrowserLaunch{
+			Browser:  name,
+			Reason:   reason,
+			Duration: time.Since(start),
+			Time:     start,
+		}
+		fmt.Printf("%s 浏览器启动完成（%s），耗时 %v\n", name, reason, launch.Duration)
+
+		entry.browser = browser
+		entry.uses = 0
+		entry.launches = append(entry.launches, launch)
+		// 事件在Playwright的消息分发goroutine中触发，关闭浏览器时可能正持有p.mu，这里不能加锁
+		disconnected := &atomic.Bool{}
+		entry.disconnected = disconnected
+		browser.OnDisconnected(func(playwright.Browser) {
+			disconnected.Store(true)
+		})
+	}
+
+	if countUse {
+		entry.uses++
+	}
+	return entry.browser, nil
+}
+
+// Launches 返回指定浏览器的所有启动记录
+func (p *BrowserPool) Launches(name string) []BrowserLaunch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[name]
+	if !ok {
+		return nil
+	}
+	return append([]BrowserLaunch(nil), entry.launches...)
+}
+
+// Release 关闭并移除指定的浏览器
+func (p *BrowserPool) Release(name string) {
+	p.mu.Lock()
+	entry, ok := p.entries[name]
+	delete(p.entries, name)
+	p.mu.Unlock()
+
+	if ok && entry.browser != nil {
+		entry.browser.Close()
+	}
+}
+
+// Close 关闭浏览器池中的所有浏览器
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.entries))
+	for name := range p.entries {
+		names = append(names, name)
+	}
+	p.mu.Unlock()
+
+	for _, name := range names {
+		p.Release(name)
+	}
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// 控制台消息级别
+const (
+	ConsoleLevelError     = "error"     // console.error
+	ConsoleLevelWarning   = "warning"   // console.warn
+	ConsoleLevelPageError = "pageerror" // 未捕获的页面错误
+)
+
+// ConsoleEntry 表示一条控制台消息或未捕获的页面错误
+type ConsoleEntry struct {
+	Level    string    // 消息级别
+	Text     string    // 消息内容
+	Location string    // 来源位置，格式为 url:行:列
+	Stack    string    // 页面错误的调用栈
+	Time     time.Time // 发生时间
+}
+
+// ConsoleWatcher 订阅页面的控制台消息和未捕获错误，并写入报告中正在执行的步骤
+type ConsoleWatcher struct {
+	mu         sync.Mutex
+	report     *ReportManager
+	levels     map[string]bool
+	pageErrors []ConsoleEntry
+}
+
+// WatchConsole 开始监听页面的console和pageerror事件，levels为空时记录error和warning
+func WatchConsole(page playwright.Page, report *ReportManager, levels []string) *ConsoleWatcher {
+	if len(levels) == 0 {
+		levels = []string{ConsoleLevelError, ConsoleLevelWarning}
+	}
+	watcher := &ConsoleWatcher{
+		report: report,
+		levels: make(map[string]bool),
+	}
+	for _, level := range levels {
+		watcher.levels[strings.ToLower(level)] = true
+	}
+
+	page.OnConsole(watcher.onConsole)
+	page.OnPageError(watcher.onPageError)
+	return watcher
+}
+
+// onConsole 处理控制台消息
+func (w *ConsoleWatcher) onConsole(message playwright.ConsoleMessage) {
+	level := strings.ToLower(message.Type())
+	if !w.levels[level] {
+		return
+	}
+
+	entry := ConsoleEntry{
+		Level: level,
+		Text:  message.Text(),
+		Time:  time.Now(),
+	}
+	if location := message.Location(); location != nil && location.URL != "" {
+		// Playwright返回的行列号从0开始
+		entry.Location = fmt.Sprintf("%s:%d:%d", location.URL, location.LineNumber+1, location.ColumnNumber+1)
+	}
+	w.report.RecordConsole(entry)
+}
+
+// onPageError 处理未捕获的页面错误
+func (w *ConsoleWatcher) onPageError(err error) {
+	entry := ConsoleEntry{
+		Level: ConsoleLevelPageError,
+		Text:  err.Error(),
+		Time:  time.Now(),
+	}
+	if pageErr, ok := err.(*playwright.Error); ok {
+		entry.Text = pageErr.Message
+		entry.Stack = pageErr.Stack
+		entry.Location = firstStackLocation(pageErr.Stack)
+	}
+
+	w.mu.Lock()
+	w.pageErrors = append(w.pageErrors, entry)
+	w.mu.Unlock()
+
+	w.report.RecordConsole(entry)
+}
+
+// PageErrors 返回监听期间发生的未捕获页面错误
+func (w *ConsoleWatcher) PageErrors() []ConsoleEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]ConsoleEntry(nil), w.pageErrors...)
+}
+
+// firstStackLocation 从调用栈中取出第一个来源位置
+func firstStackLocation(stack string) string {
+	for _, line := range strings.Split(stack, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "at ") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "at ")
+		if start := strings.LastIndex(line, "("); start >= 0 && strings.HasSuffix(line, ")") {
+			line = line[start+1 : len(line)-1]
+		}
+		return line
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// ApplyEmulation 根据浏览器配置设置上下文的设备与视口模拟选项
+func ApplyEmulation(options *playwright.BrowserNewContextOptions, devices map[string]*playwright.DeviceDescriptor, browserConfig config.BrowserConfig) error {
+	// 先应用设备描述
+	if browserConfig.Device != "" {
+		device, ok := devices[browserConfig.Device]
+		if !ok {
+			return fmt.Errorf("未知的设备描述: %s", browserConfig.Device)
+		}
+		options.UserAgent = playwright.String(device.UserAgent)
+		options.Viewport = device.Viewport
+		options.Screen = device.Screen
+		options.DeviceScaleFactor = playwright.Float(device.DeviceScaleFactor)
+		options.IsMobile = playwright.Bool(device.IsMobile)
+		options.HasTouch = playwright.Bool(device.HasTouch)
+	}
+
+	// 显式配置的视口优先；没有设备和视口配置时按最大化处理
+	if browserConfig.Viewport != nil {
+		options.Viewport = &playwright.Size{
+			Width:  browserConfig.Viewport.Width,
+			Height: browserConfig.Viewport.Height,
+		}
+	} else if browserConfig.Device == "" && browserConfig.Maximized {
+		// 设置一个足够大的视口大小来模拟最大化
+		options.Viewport = &playwright.Size{
+			Width:  1920,
+			Height: 1080,
+		}
+	}
+
+	// 其余显式配置覆盖设备描述
+	if browserConfig.DeviceScaleFactor > 0 {
+		options.DeviceScaleFactor = playwright.Float(browserConfig.DeviceScaleFactor)
+	}
+	if browserConfig.IsMobile != nil {
+		options.IsMobile = playwright.Bool(*browserConfig.IsMobile)
+	}
+	if browserConfig.HasTouch != nil {
+		options.HasTouch = playwright.Bool(*browserConfig.HasTouch)
+	}
+	if browserConfig.UserAgent != "" {
+		options.UserAgent = playwright.String(browserConfig.UserAgent)
+	}
+	if browserConfig.Locale != "" {
+		options.Locale = playwright.String(browserConfig.Locale)
+	}
+	if browserConfig.TimezoneID != "" {
+		options.TimezoneId = playwright.String(browserConfig.TimezoneID)
+	}
+
+	// firefox不支持isMobile
+	if browserConfig.Type == "firefox" && options.IsMobile != nil && *options.IsMobile {
+		return fmt.Errorf("firefox 不支持移动设备模拟（is_mobile），请改用 chromium 或 webkit")
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// evidenceTimeout 保存失败现场的最长等待时间，页面无响应时放弃，不影响测试结束
+const evidenceTimeout = 5 * time.Second
+
+// FailureEvidence 步骤失败、超时或测试panic时的页面状态
+type FailureEvidence struct {
+	URL          string // 页面URL
+	Title        string // 页面标题
+	HTML         string // 页面HTML文件
+	AriaSnapshot string // 页面无障碍树快照文件（YAML格式）
+}
+
+// captureEvidence 保存页面的URL、标题、HTML和无障碍树快照，prefix 由 reserveFrame 分配，与失败截图同名
+// 没有页面时返回nil；HTML或快照保存失败时对应的路径为空
+func (r *ReportManager) captureEvidence(page playwright.Page, stepName, prefix string) *FailureEvidence {
+	if page == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(prefix), 0755); err != nil {
+		fmt.Printf("无法创建步骤截图目录: %v\n", err)
+		return nil
+	}
+
+	// 读取页面内容需要等待Playwright响应，超时后返回已经得到的URL
+	evidence := &FailureEvidence{URL: page.URL()}
+	ctx, cancel := context.WithTimeout(context.Background(), evidenceTimeout)
+	defer cancel()
+	var captured FailureEvidence
+	if _, _, err := RunWithContext(ctx, func() bool {
+		captured = savePageState(page, stepName, prefix)
+		return true
+	}); err != nil {
+		fmt.Printf("保存步骤 %s 的页面状态超时\n", stepName)
+		return evidence
+	}
+	evidence.Title = captured.Title
+	evidence.HTML = captured.HTML
+	evidence.AriaSnapshot = captured.AriaSnapshot
+	return evidence
+}
+
+// savePageState 读取页面标题，将HTML和无障碍树快照保存为 prefix.html 和 prefix-aria.txt
+func savePageState(page playwright.Page, stepName, prefix string) FailureEvidence {
+	var state FailureEvidence
+	if title, err := page.Title(); err == nil {
+		state.Title = title
+	} else {
+		fmt.Printf("无法读取步骤 %s 的页面标题: %v\n", stepName, err)
+	}
+
+	if content, err := page.Content(); err != nil {
+		fmt.Printf("无法读取步骤 %s 的页面HTML: %v\n", stepName, err)
+	} else if err := os.WriteFile(prefix+".html", []byte(content), 0644); err != nil {
+		fmt.Printf("无法保存步骤 %s 的页面HTML: %v\n", stepName, err)
+	} else {
+		state.HTML = prefix + ".html"
+	}
+
+	snapshot, err := page.Locator("body").AriaSnapshot(playwright.LocatorAriaSnapshotOptions{
+		Timeout: playwright.Float(float64(evidenceTimeout.Milliseconds())),
+	})
+	if err != nil {
+		fmt.Printf("无法读取步骤 %s 的无障碍树快照: %v\n", stepName, err)
+	} else if err := os.WriteFile(prefix+"-aria.txt", []byte(snapshot), 0644); err != nil {
+		fmt.Printf("无法保存步骤 %s 的无障碍树快照: %v\n", stepName, err)
+	} else {
+		state.AriaSnapshot = prefix + "-aria.txt"
+	}
+	return state
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FixtureScope 夹具的生命周期
+type FixtureScope string
+
+const (
+	ScopeTest    FixtureScope = "test"    // 每个测试创建一次，测试结束后清理
+	ScopeBrowser FixtureScope = "browser" // 每个浏览器创建一次，该浏览器的测试全部结束后清理
+	ScopeRun     FixtureScope = "run"     // 整次运行创建一次，运行结束后清理
+)
+
+// scopeRank 生命周期的长短，数值越大生命周期越长
+var scopeRank = map[FixtureScope]int{
+	ScopeTest:    0,
+	ScopeBrowser: 1,
+	ScopeRun:     2,
+}
+
+// FixtureSetup 创建夹具的值，可以通过 fixtures 获取依赖的其他夹具；teardown 为空表示无需清理
+type FixtureSetup func(fixtures *Fixtures) (value any, teardown func() error, err error)
+
+// Fixture 夹具定义
+type Fixture struct {
+	Name  string
+	Scope FixtureScope
+	Setup FixtureSetup
+}
+
+// FixtureRegistry 已注册的夹具定义
+type FixtureRegistry struct {
+	fixtures map[string]Fixture
+}
+
+// NewFixtureRegistry 创建夹具注册表
+func NewFixtureRegistry(fixtures ...Fixture) *FixtureRegistry {
+	registry := &FixtureRegistry{fixtures: map[string]Fixture{}}
+	for _, fixture := range fixtures {
+		registry.Register(fixture)
+	}
+	return registry
+}
+
+// Register 注册夹具定义，同名的定义会被覆盖
+func (r *FixtureRegistry) Register(fixture Fixture) {
+	r.fixtures[fixture.Name] = fixture
+}
+
+// fixtureTeardown 一个已创建夹具的清理函数
+type fixtureTeardown struct {
+	name     string
+	teardown func() error
+}
+
+// Fixtures 一个生命周期内的夹具，按需创建并缓存，Teardown 时按创建的相反顺序清理
+// 测试级别的 Fixtures 以浏览器级别的为上级，浏览器级别的以整次运行级别的为上级
+// 测试超时后仍在执行的测试代码可能与清理或下一个测试同时访问夹具，因此各方法都加锁；
+// 创建夹具时不持有锁，夹具的 Setup 可以获取其他夹具
+type Fixtures struct {
+	scope     FixtureScope
+	parent    *Fixtures
+	registry  *FixtureRegistry
+	mu        sync.Mutex // 保护以下字段
+	values    map[string]any
+	errs      map[string]error
+	teardowns []fixtureTeardown
+	resolving []string // 正在创建的夹具，用于检测循环依赖
+}
+
+// NewFixtures 创建指定生命周期的夹具集合，parent 为上一级生命周期的夹具集合
+func NewFixtures(registry *FixtureRegistry, scope FixtureScope, parent *Fixtures) *Fixtures {
+	return &Fixtures{
+		scope:    scope,
+		parent:   parent,
+		registry: registry,
+		values:   map[string]any{},
+		errs:     map[string]error{},
+	}
+}
+
+// Provide 直接提供夹具的值，用于由运行器创建的对象（如浏览器、页面），不需要清理
+func (f *Fixtures) Provide(name string, value any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[name] = value
+}
+
+// Get 获取夹具，首次获取时在夹具定义的生命周期中创建；创建失败时记录错误，同一生命周期内不再重试
+func (f *Fixtures) Get(name string) (any, error) {
+	// 由运行器提供的值可以在当前或上级生命周期中找到
+	for holder := f; holder != nil; holder = holder.parent {
+		if value, ok := holder.lookup(name); ok {
+			return value, nil
+		}
+	}
+
+	fixture, ok := f.registry.fixtures[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的夹具: %s", name)
+	}
+	holder := f.holder(fixture.Scope)
+	if holder == nil {
+		return nil, fmt.Errorf("无法在 %s 生命周期中使用 %s 生命周期的夹具 %s", f.scope, fixture.Scope, name)
+	}
+	return holder.create(fixture)
+}
+
+// lookup 返回当前集合中已有的夹具值
+func (f *Fixtures) lookup(name string) (any, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.values[name]
+	return value, ok
+}
+
+// Resolve 依次获取多个夹具，返回第一个错误
+func (f *Fixtures) Resolve(names ...string) error {
+	for _, name := range names {
+		if _, err := f.Get(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// holder 返回保存指定生命周期夹具的集合，生命周期比当前集合短时返回nil
+func (f *Fixtures) holder(scope FixtureScope) *Fixtures {
+	for holder := f; holder != nil; holder = holder.parent {
+		if holder.scope == scope {
+			return holder
+		}
+		if scopeRank[holder.scope] > scopeRank[scope] {
+			return nil
+		}
+	}
+	return nil
+}
+
+// create 在当前集合中创建夹具，依赖只能从当前及更长的生命周期中获取
+func (f *Fixtures) create(fixture Fixture) (any, error) {
+	f.mu.Lock()
+	if err, ok := f.errs[fixture.Name]; ok {
+		f.mu.Unlock()
+		return nil, err
+	}
+	if value, ok := f.values[fixture.Name]; ok {
+		f.mu.Unlock()
+		return value, nil
+	}
+	for _, name := range f.resolving {
+		if name == fixture.Name {
+			f.mu.Unlock()
+			return nil, fmt.Errorf("夹具循环依赖: %s -> %s", strings.Join(f.resolving, " -> "), fixture.Name)
+		}
+	}
+	f.resolving = append(f.resolving, fixture.Name)
+	f.mu.Unlock()
+
+	value, teardown, err := fixture.Setup(f)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.resolving) - 1; i >= 0; i-- {
+		if f.resolving[i] == fixture.Name {
+			f.resolving = append(f.resolving[:i], f.resolving[i+1:]...)
+			break
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("无法创建夹具 %s: %w", fixture.Name, err)
+		f.errs[fixture.Name] = err
+		return nil, err
+	}
+
+	f.values[fixture.Name] = value
+	if teardown != nil {
+		f.teardowns = append(f.teardowns, fixtureTeardown{name: fixture.Name, teardown: teardown})
+	}
+	return value, nil
+}
+
+// Teardown 按创建的相反顺序清理夹具，某个夹具清理失败时继续清理其余夹具
+func (f *Fixtures) Teardown() error {
+	f.mu.Lock()
+	teardowns := f.teardowns
+	f.teardowns = nil
+	f.values = map[string]any{}
+	f.errs = map[string]error{}
+	f.mu.Unlock()
+
+	var errs []error
+	for i := len(teardowns) - 1; i >= 0; i-- {
+		if err := teardowns[i].teardown(); err != nil {
+			errs = append(errs, fmt.Errorf("清理夹具 %s 失败: %w", teardowns[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// UseFixture 获取指定类型的夹具
+func UseFixture[T any](fixtures *Fixtures, name string) (T, error) {
+	var zero T
+	value, err := fixtures.Get(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("夹具 %s 的类型为 %T，不是 %T", name, value, zero)
+	}
+	return typed, nil
+}
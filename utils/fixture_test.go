@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fixtureEvents 记录夹具的创建和清理顺序
+type fixtureEvents []string
+
+// define 返回一个依赖 deps 的夹具定义，创建和清理时记录事件
+func (e *fixtureEvents) define(name string, scope FixtureScope, deps ...string) Fixture {
+	return Fixture{
+		Name:  name,
+		Scope: scope,
+		Setup: func(fixtures *Fixtures) (any, func() error, error) {
+			if err := fixtures.Resolve(deps...); err != nil {
+				return nil, nil, err
+			}
+			*e = append(*e, "+"+name)
+			return name, func() error {
+				*e = append(*e, "-"+name)
+				return nil
+			}, nil
+		},
+	}
+}
+
+func (e fixtureEvents) String() string {
+	return strings.Join(e, " ")
+}
+
+func TestFixturesTeardownReverseOrder(t *testing.T) {
+	var events fixtureEvents
+	registry := NewFixtureRegistry(
+		events.define("db", ScopeTest),
+		events.define("user", ScopeTest, "db"),
+		events.define("page", ScopeTest),
+	)
+	fixtures := NewFixtures(registry, ScopeTest, nil)
+	if err := fixtures.Resolve("user", "page", "db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fixtures.Teardown(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := events.String(), "+db +user +page -page -user -db"; got != want {
+		t.Errorf("事件顺序 %q，期望 %q", got, want)
+	}
+}
+
+func TestFixturesTeardownContinuesAfterError(t *testing.T) {
+	var events fixtureEvents
+	registry := NewFixtureRegistry(events.define("first", ScopeTest), Fixture{
+		Name:  "broken",
+		Scope: ScopeTest,
+		Setup: func(*Fixtures) (any, func() error, error) {
+			return nil, func() error { return errors.New("连接已断开") }, nil
+		},
+	})
+	fixtures := NewFixtures(registry, ScopeTest, nil)
+	if err := fixtures.Resolve("first", "broken"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := fixtures.Teardown()
+	if err == nil || err.Error() != "清理夹具 broken 失败: 连接已断开" {
+		t.Errorf("Teardown() = %v", err)
+	}
+	if events.String() != "+first -first" {
+		t.Errorf("清理失败后其余夹具应照常清理: %q", events)
+	}
+}
+
+func TestFixturesSharedAcrossScopes(t *testing.T) {
+	var events fixtureEvents
+	registry := NewFixtureRegistry(
+		events.define("server", ScopeRun),
+		events.define("session", ScopeBrowser, "server"),
+		events.define("form", ScopeTest, "session"),
+	)
+	run := NewFixtures(registry, ScopeRun, nil)
+	browser := NewFixtures(registry, ScopeBrowser, run)
+	for i := 0; i < 2; i++ {
+		test := NewFixtures(registry, ScopeTest, browser)
+		if _, err := UseFixture[string](test, "form"); err != nil {
+			t.Fatal(err)
+		}
+		test.Teardown()
+	}
+	browser.Teardown()
+	run.Teardown()
+
+	if got, want := events.String(), "+server +session +form -form +form -form -session -server"; got != want {
+		t.Errorf("事件顺序 %q，期望 %q", got, want)
+	}
+}
+
+func TestFixturesScopeErrors(t *testing.T) {
+	var events fixtureEvents
+	registry := NewFixtureRegistry(
+		events.define("form", ScopeTest),
+		events.define("session", ScopeBrowser, "form"),
+		events.define("a", ScopeTest, "b"),
+		events.define("b", ScopeTest, "a"),
+	)
+	browser := NewFixtures(registry, ScopeBrowser, NewFixtures(registry, ScopeRun, nil))
+	test := NewFixtures(registry, ScopeTest, browser)
+
+	_, err := test.Get("session")
+	if err == nil || !strings.Contains(err.Error(), "无法在 browser 生命周期中使用 test 生命周期的夹具 form") {
+		t.Errorf("浏览器级别的夹具依赖测试级别的夹具时应返回错误: %v", err)
+	}
+	// 创建失败的结果会被缓存，不再重试
+	if _, again := browser.Get("session"); again == nil || again.Error() != err.Error() {
+		t.Errorf("第二次获取 session = %v，期望返回相同的错误", again)
+	}
+
+	if _, err := test.Get("a"); err == nil || !strings.Contains(err.Error(), "夹具循环依赖: a -> b -> a") {
+		t.Errorf("Get(a) = %v，期望循环依赖错误", err)
+	}
+	if _, err := test.Get("missing"); err == nil || err.Error() != "未知的夹具: missing" {
+		t.Errorf("Get(missing) = %v", err)
+	}
+}
+
+func TestUseFixtureType(t *testing.T) {
+	fixtures := NewFixtures(NewFixtureRegistry(), ScopeTest, nil)
+	fixtures.Provide("browser", "chromium")
+
+	if name, err := UseFixture[string](fixtures, "browser"); err != nil || name != "chromium" {
+		t.Errorf("UseFixture[string] = %q, %v", name, err)
+	}
+	if _, err := UseFixture[int](fixtures, "browser"); err == nil || !strings.Contains(err.Error(), "类型为 string，不是 int") {
+		t.Errorf("UseFixture[int] = %v，期望类型错误", err)
+	}
+}
+
+func TestFixturesConcurrentTeardown(t *testing.T) {
+	// 超时的测试goroutine仍在获取夹具时，运行器可能同时清理夹具集合
+	registry := NewFixtureRegistry(Fixture{
+		Name:  "token",
+		Scope: ScopeTest,
+		Setup: func(*Fixtures) (any, func() error, error) {
+			return "token", func() error { return nil }, nil
+		},
+	})
+	fixtures := NewFixtures(registry, ScopeTest, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			fixtures.Get("token")
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		fixtures.Teardown()
+	}
+	<-done
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// FlakyScore 一个测试在一个浏览器上的不稳定程度
+type FlakyScore struct {
+	Runs   int     // 参与计算的运行次数
+	Flips  int     // 相邻两次运行结果不同的次数
+	Score  float64 // 翻转比例(0-1)
+	Flaky  bool    // 是否判定为不稳定
+	Passed int     // 通过次数
+}
+
+// Percent 返回以百分比表示的翻转比例，便于报告展示
+func (f FlakyScore) Percent() string {
+	return fmt.Sprintf("%.0f%%", f.Score*100)
+}
+
+// FlakyDetector 根据历史结果计算每个测试在每个浏览器上的不稳定程度
+type FlakyDetector struct {
+	config  config.FlakyConfig
+	history map[[2]string][]bool // 按时间排序的历次结果，true表示通过
+}
+
+// NewFlakyDetector 根据历史记录创建不稳定测试检测器，只使用本次运行之前的记录
+func NewFlakyDetector(records []HistoryRecord, flakyConfig config.FlakyConfig) *FlakyDetector {
+	sorted := append([]HistoryRecord(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	history := map[[2]string][]bool{}
+	for _, record := range sorted {
+		key := [2]string{record.Browser, record.Test}
+		history[key] = append(history[key], record.Status == "Success")
+	}
+
+	return &FlakyDetector{
+		config:  flakyConfig,
+		history: history,
+	}
+}
+
+// Score 计算测试在浏览器上最近若干次运行的不稳定程度
+func (d *FlakyDetector) Score(browser, test string) FlakyScore {
+	results := d.history[[2]string{browser, test}]
+	if d.config.Window > 0 && len(results) > d.config.Window {
+		results = results[len(results)-d.config.Window:]
+	}
+
+	score := FlakyScore{Runs: len(results)}
+	for i, passed := range results {
+		if passed {
+			score.Passed++
+		}
+		if i > 0 && passed != results[i-1] {
+			score.Flips++
+		}
+	}
+	if score.Runs > 1 {
+		score.Score = float64(score.Flips) / float64(score.Runs-1)
+	}
+	score.Flaky = score.Runs >= d.config.MinRuns && score.Flips > 0 && score.Score >= d.config.Threshold
+	return score
+}
+
+// Quarantined 判断测试是否被隔离：开启隔离且历史结果判定为不稳定
+func (d *FlakyDetector) Quarantined(browser, test string) bool {
+	return d.config.Quarantine && d.Score(browser, test).Flaky
+}
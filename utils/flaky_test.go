@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// runs 按 outcomes 生成历史记录，P 表示通过，F 表示失败，每次运行间隔一分钟
+func runs(browser, test, outcomes string) []HistoryRecord {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	records := make([]HistoryRecord, 0, len(outcomes))
+	for i, outcome := range outcomes {
+		status := "Failure"
+		if outcome == 'P' {
+			status = "Success"
+		}
+		records = append(records, HistoryRecord{Time: start.Add(time.Duration(i) * time.Minute), Browser: browser, Test: test, Status: status})
+	}
+	return records
+}
+
+func TestFlakyScoreFlips(t *testing.T) {
+	flakyConfig := config.FlakyConfig{MinRuns: 3, Threshold: 0.5}
+
+	score := NewFlakyDetector(runs("chromium", "登录", "PFPF"), flakyConfig).Score("chromium", "登录")
+	if score.Runs != 4 || score.Flips != 3 || score.Passed != 2 || !score.Flaky || score.Percent() != "100%" {
+		t.Errorf("PFPF: %+v", score)
+	}
+
+	// 稳定地失败不算不稳定
+	score = NewFlakyDetector(runs("chromium", "登录", "FFFF"), flakyConfig).Score("chromium", "登录")
+	if score.Flips != 0 || score.Flaky {
+		t.Errorf("FFFF: %+v", score)
+	}
+
+	// 一次翻转，比例 1/4 低于阈值
+	score = NewFlakyDetector(runs("chromium", "登录", "PPPFF"), flakyConfig).Score("chromium", "登录")
+	if score.Flips != 1 || score.Score != 0.25 || score.Flaky {
+		t.Errorf("PPPFF: %+v", score)
+	}
+}
+
+func TestFlakyScoreMinRuns(t *testing.T) {
+	detector := NewFlakyDetector(runs("webkit", "登录", "PF"), config.FlakyConfig{MinRuns: 3, Threshold: 0.5})
+	if score := detector.Score("webkit", "登录"); score.Flaky {
+		t.Errorf("运行次数少于 min_runs 时不应判定为不稳定: %+v", score)
+	}
+	if score := detector.Score("webkit", "不存在的测试"); score.Runs != 0 || score.Flaky {
+		t.Errorf("没有历史记录时: %+v", score)
+	}
+}
+
+func TestFlakyScoreWindow(t *testing.T) {
+	// 窗口为 3 时只计算最后的 PPP
+	detector := NewFlakyDetector(runs("chromium", "登录", "FPFPPP"), config.FlakyConfig{Window: 3, MinRuns: 3, Threshold: 0.5})
+	if score := detector.Score("chromium", "登录"); score.Runs != 3 || score.Flips != 0 || score.Flaky {
+		t.Errorf("窗口之外的结果不应计入: %+v", score)
+	}
+}
+
+func TestFlakyDetectorOrderAndQuarantine(t *testing.T) {
+	records := append(runs("chromium", "登录", "PFP"), runs("firefox", "登录", "PPP")...)
+	// 历史记录乱序时按时间排序：PFP 的前两条交换后仍应得到两次翻转
+	records[0], records[1] = records[1], records[0]
+
+	quarantine := NewFlakyDetector(records, config.FlakyConfig{MinRuns: 3, Threshold: 0.5, Quarantine: true})
+	if score := quarantine.Score("chromium", "登录"); score.Flips != 2 {
+		t.Errorf("chromium: %+v，期望翻转 2 次", score)
+	}
+	if !quarantine.Quarantined("chromium", "登录") || quarantine.Quarantined("firefox", "登录") {
+		t.Error("只应隔离 chromium 上不稳定的测试")
+	}
+
+	detector := NewFlakyDetector(records, config.FlakyConfig{MinRuns: 3, Threshold: 0.5})
+	if detector.Quarantined("chromium", "登录") {
+		t.Error("未开启隔离时不应隔离测试")
+	}
+}
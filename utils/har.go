@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// DefaultHARDir 默认HAR文件目录
+const DefaultHARDir = "./hars"
+
+// HARPath 返回指定浏览器和测试对应的HAR文件路径，录制与回放使用相同的路径
+func HARPath(harConfig config.HARConfig, browserName, testName string) string {
+	dir := harConfig.Dir
+	if dir == "" {
+		dir = DefaultHARDir
+	}
+	return filepath.Join(BrowserDir(dir, browserName), sanitizeFileName(testName)+".har")
+}
+
+// EnableHARRecording 在上下文选项中开启HAR录制，HAR文件在上下文关闭时写入
+func EnableHARRecording(options *playwright.BrowserNewContextOptions, harConfig config.HARConfig, harPath string) error {
+	if err := os.MkdirAll(filepath.Dir(harPath), 0755); err != nil {
+		return fmt.Errorf("无法创建HAR目录: %w", err)
+	}
+
+	options.RecordHarPath = playwright.String(harPath)
+	options.RecordHarContent = playwright.HarContentPolicyEmbed
+	options.RecordHarMode = playwright.HarModeFull
+	if harConfig.URLFilter != "" {
+		options.RecordHarURLFilter = harConfig.URLFilter
+	}
+	return nil
+}
+
+// ReplayHAR 让浏览器上下文使用之前录制的HAR文件响应请求
+func ReplayHAR(context playwright.BrowserContext, harConfig config.HARConfig, harPath string) error {
+	if _, err := os.Stat(harPath); err != nil {
+		return fmt.Errorf("找不到用于回放的HAR文件 %s: %w", harPath, err)
+	}
+
+	options := playwright.BrowserContextRouteFromHAROptions{
+		NotFound: playwright.HarNotFoundFallback,
+	}
+	if harConfig.Strict {
+		options.NotFound = playwright.HarNotFoundAbort
+	}
+	if harConfig.URLFilter != "" {
+		options.URL = harConfig.URLFilter
+	}
+
+	if err := context.RouteFromHAR(harPath, options); err != nil {
+		return fmt.Errorf("加载HAR文件 %s 失败: %w", harPath, err)
+	}
+	return nil
+}
+
+// sanitizeFileName 将测试名称转换为可用作文件名的字符串
+func sanitizeFileName(name string) string {
+	replacer := strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_", " ", "_")
+	return replacer.Replace(name)
+}
+
+// BrowserDir 返回 base 下以浏览器条目名称命名的子目录，名称中的 / 和空格等字符替换为下划线
+func BrowserDir(base, browserName string) string {
+	return filepath.Join(base, sanitizeFileName(browserName))
+}
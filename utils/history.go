@@ -0,0 +1,517 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryRecord 历史结果中的一条测试记录
+type HistoryRecord struct {
+	RunID      string    `json:"run_id"`      // 运行ID，同一次运行的所有浏览器共用
+	Time       time.Time `json:"time"`        // 测试开始时间
+	Browser    string    `json:"browser"`     // 浏览器条目名称
+	Test       string    `json:"test"`        // 测试名称
+	Status     string    `json:"status"`      // 测试状态
+	DurationMs int64     `json:"duration_ms"` // 测试耗时(ms)
+}
+
+// HistoryStore 以JSON Lines追加写入的历史结果文件
+type HistoryStore struct {
+	path string
+}
+
+// NewHistoryStore 创建历史结果存储，结果保存在 dir/results.jsonl
+func NewHistoryStore(dir string) *HistoryStore {
+	if dir == "" {
+		dir = "./history"
+	}
+	return &HistoryStore{path: filepath.Join(dir, "results.jsonl")}
+}
+
+// Path 返回历史结果文件路径
+func (h *HistoryStore) Path() string {
+	return h.path
+}
+
+// Append 追加写入测试记录
+func (h *HistoryStore) Append(records []HistoryRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("无法创建历史结果目录: %w", err)
+	}
+
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("无法打开历史结果文件: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("写入历史结果失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load 读取全部历史记录，无法解析的行会被跳过
+func (h *HistoryStore) Load() ([]HistoryRecord, error) {
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法打开历史结果文件: %w", err)
+	}
+	defer file.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record HistoryRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			fmt.Printf("跳过无法解析的历史记录: %v\n", err)
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史结果失败: %w", err)
+	}
+	return records, nil
+}
+
+// TrendPoint 趋势图中的一个数据点，对应一次运行
+type TrendPoint struct {
+	RunID      string
+	Time       time.Time
+	Status     string // 该次运行中没有执行时为空
+	DurationMs int64
+}
+
+// TrendSeries 一个测试在一个浏览器上的历史趋势
+type TrendSeries struct {
+	Browser     string
+	Test        string
+	Points      []TrendPoint
+	Runs        int     // 执行次数
+	PassRate    float64 // 通过率(%)
+	AvgDuration int64   // 平均耗时(ms)
+}
+
+// DurationPolyline 返回耗时折线图的SVG坐标
+func (s TrendSeries) DurationPolyline() string {
+	var maxDuration int64
+	for _, point := range s.Points {
+		maxDuration = max(maxDuration, point.DurationMs)
+	}
+	var values []float64
+	for _, point := range s.Points {
+		if point.Status == "" {
+			values = append(values, -1)
+			continue
+		}
+		ratio := 0.0
+		if maxDuration > 0 {
+			ratio = float64(point.DurationMs) / float64(maxDuration)
+		}
+		values = append(values, ratio)
+	}
+	return polyline(values)
+}
+
+// BrowserTrend 一个浏览器每次运行的通过率
+type BrowserTrend struct {
+	Browser string
+	Points  []BrowserTrendPoint
+}
+
+// BrowserTrendPoint 一个浏览器在一次运行中的结果
+type BrowserTrendPoint struct {
+	RunID    string
+	Time     time.Time
+	Total    int
+	Passed   int
+	PassRate float64 // 通过率(%)
+}
+
+// PassRatePolyline 返回通过率折线图的SVG坐标
+func (b BrowserTrend) PassRatePolyline() string {
+	var values []float64
+	for _, point := range b.Points {
+		if point.Total == 0 {
+			values = append(values, -1)
+			continue
+		}
+		values = append(values, point.PassRate/100)
+	}
+	return polyline(values)
+}
+
+// Latest 返回最近一次运行的结果
+func (b BrowserTrend) Latest() BrowserTrendPoint {
+	if len(b.Points) == 0 {
+		return BrowserTrendPoint{}
+	}
+	return b.Points[len(b.Points)-1]
+}
+
+// trendChartWidth 和 trendChartHeight 趋势图的尺寸
+const (
+	trendChartWidth  = 300
+	trendChartHeight = 60
+)
+
+// polyline 将0-1之间的值转换为SVG折线坐标，负值表示缺失的数据点
+func polyline(values []float64) string {
+	var points []string
+	step := 0.0
+	if len(values) > 1 {
+		step = float64(trendChartWidth) / float64(len(values)-1)
+	}
+	for i, value := range values {
+		if value < 0 {
+			continue
+		}
+		x := step * float64(i)
+		y := float64(trendChartHeight) - value*float64(trendChartHeight-4) - 2
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return strings.Join(points, " ")
+}
+
+// historyRun 历史记录中的一次运行
+type historyRun struct {
+	id   string
+	time time.Time
+}
+
+// recentRuns 返回按时间排序的最近maxRuns次运行，maxRuns为0时返回全部
+func recentRuns(records []HistoryRecord, maxRuns int) []historyRun {
+	first := map[string]time.Time{}
+	for _, record := range records {
+		if t, ok := first[record.RunID]; !ok || record.Time.Before(t) {
+			first[record.RunID] = record.Time
+		}
+	}
+	runs := make([]historyRun, 0, len(first))
+	for id, t := range first {
+		runs = append(runs, historyRun{id: id, time: t})
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].time.Before(runs[j].time)
+	})
+	if maxRuns > 0 && len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+	return runs
+}
+
+// BuildTrends 根据历史记录计算每个浏览器和每个测试的趋势
+func BuildTrends(records []HistoryRecord, maxRuns int) ([]BrowserTrend, []TrendSeries) {
+	runs := recentRuns(records, maxRuns)
+	runIndex := map[string]int{}
+	for i, run := range runs {
+		runIndex[run.id] = i
+	}
+
+	browserPoints := map[string][]BrowserTrendPoint{}
+	seriesPoints := map[[2]string][]TrendPoint{}
+	for _, record := range records {
+		index, ok := runIndex[record.RunID]
+		if !ok {
+			continue
+		}
+
+		points, ok := browserPoints[record.Browser]
+		if !ok {
+			points = make([]BrowserTrendPoint, len(runs))
+			for i, run := range runs {
+				points[i] = BrowserTrendPoint{RunID: run.id, Time: run.time}
+			}
+			browserPoints[record.Browser] = points
+		}
+		points[index].Total++
+		if record.Status == "Success" {
+			points[index].Passed++
+		}
+
+		key := [2]string{record.Browser, record.Test}
+		series, ok := seriesPoints[key]
+		if !ok {
+			series = make([]TrendPoint, len(runs))
+			for i, run := range runs {
+				series[i] = TrendPoint{RunID: run.id, Time: run.time}
+			}
+			seriesPoints[key] = series
+		}
+		series[index].Status = record.Status
+		series[index].DurationMs = record.DurationMs
+	}
+
+	var browsers []BrowserTrend
+	for browser, points := range browserPoints {
+		for i := range points {
+			if points[i].Total > 0 {
+				points[i].PassRate = float64(points[i].Passed) / float64(points[i].Total) * 100
+			}
+		}
+		browsers = append(browsers, BrowserTrend{Browser: browser, Points: points})
+	}
+	sort.Slice(browsers, func(i, j int) bool {
+		return browsers[i].Browser < browsers[j].Browser
+	})
+
+	var series []TrendSeries
+	for key, points := range seriesPoints {
+		trend := TrendSeries{Browser: key[0], Test: key[1], Points: points}
+		passed := 0
+		var totalDuration int64
+		for _, point := range points {
+			if point.Status == "" {
+				continue
+			}
+			trend.Runs++
+			totalDuration += point.DurationMs
+			if point.Status == "Success" {
+				passed++
+			}
+		}
+		if trend.Runs > 0 {
+			trend.PassRate = float64(passed) / float64(trend.Runs) * 100
+			trend.AvgDuration = totalDuration / int64(trend.Runs)
+		}
+		series = append(series, trend)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Browser != series[j].Browser {
+			return series[i].Browser < series[j].Browser
+		}
+		return series[i].Test < series[j].Test
+	})
+
+	return browsers, series
+}
+
+// GenerateTrendsReport 根据历史记录生成趋势页面，maxRuns为展示的最近运行次数
+func GenerateTrendsReport(store *HistoryStore, path string, maxRuns int) error {
+	records, err := store.Load()
+	if err != nil {
+		return err
+	}
+	browsers, series := BuildTrends(records, maxRuns)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建趋势页面目录: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("无法创建趋势页面: %w", err)
+	}
+	defer file.Close()
+
+	funcMap := template.FuncMap{
+		"lower": strings.ToLower,
+	}
+	tmpl := template.Must(template.New("trends").Funcs(funcMap).Parse(trendsTemplate))
+	data := struct {
+		GeneratedAt time.Time
+		Runs        int
+		Browsers    []BrowserTrend
+		Series      []TrendSeries
+		Width       int
+		Height      int
+	}{
+		GeneratedAt: time.Now(),
+		Runs:        len(recentRuns(records, maxRuns)),
+		Browsers:    browsers,
+		Series:      series,
+		Width:       trendChartWidth,
+		Height:      trendChartHeight,
+	}
+	if err := tmpl.Execute(file, data); err != nil {
+		return fmt.Errorf("生成趋势页面失败: %w", err)
+	}
+	return nil
+}
+
+// trendsTemplate 趋势页面模板
+const trendsTemplate = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>测试趋势</title>
+    <style>
+        :root {
+            --success-color: #28a745;
+            --failure-color: #dc3545;
+            --timeout-color: #fd7e14;
+            --running-color: #17a2b8;
+            --neutral-color: #6c757d;
+            --light-bg: #f8f9fa;
+            --border-radius: 8px;
+            --box-shadow: 0 2px 5px rgba(0,0,0,0.1);
+        }
+
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, "Helvetica Neue", Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            background-color: #f5f5f5;
+            margin: 0;
+            padding: 20px;
+        }
+
+        .container {
+            max-width: 1200px;
+            margin: 0 auto;
+        }
+
+        section {
+            background-color: white;
+            border-radius: var(--border-radius);
+            box-shadow: var(--box-shadow);
+            padding: 20px;
+            margin-bottom: 20px;
+        }
+
+        table {
+            width: 100%;
+            border-collapse: collapse;
+        }
+
+        th, td {
+            padding: 8px;
+            border-bottom: 1px solid #eee;
+            text-align: left;
+            vertical-align: middle;
+        }
+
+        th {
+            background-color: var(--light-bg);
+        }
+
+        svg.chart {
+            background-color: var(--light-bg);
+            border-radius: 4px;
+        }
+
+        svg.chart polyline {
+            fill: none;
+            stroke: var(--running-color);
+            stroke-width: 2;
+        }
+
+        .status-strip {
+            display: flex;
+            gap: 2px;
+        }
+
+        .status-cell {
+            width: 10px;
+            height: 20px;
+            border-radius: 2px;
+            background-color: #e9ecef;
+        }
+
+        .status-cell.success {
+            background-color: var(--success-color);
+        }
+
+        .status-cell.failure {
+            background-color: var(--failure-color);
+        }
+
+        .status-cell.timeout {
+            background-color: var(--timeout-color);
+        }
+
+        .muted {
+            color: var(--neutral-color);
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>测试趋势</h1>
+        <p class="muted">最近 {{.Runs}} 次运行，生成时间 {{.GeneratedAt.Format "2006-01-02 15:04:05"}}</p>
+
+        <section>
+            <h2>各浏览器通过率</h2>
+            {{if .Browsers}}
+            <table>
+                <thead>
+                    <tr><th>浏览器</th><th>通过率趋势</th><th>最近一次</th></tr>
+                </thead>
+                <tbody>
+                    {{range .Browsers}}
+                    <tr>
+                        <td>{{.Browser}}</td>
+                        <td>
+                            <svg class="chart" width="{{$.Width}}" height="{{$.Height}}" viewBox="0 0 {{$.Width}} {{$.Height}}">
+                                <polyline points="{{.PassRatePolyline}}"></polyline>
+                            </svg>
+                        </td>
+                        <td>{{with .Latest}}{{if .Total}}{{.Passed}}/{{.Total}}（{{printf "%.0f" .PassRate}}%）{{else}}-{{end}}{{end}}</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+            {{else}}
+            <p class="muted">暂无历史记录</p>
+            {{end}}
+        </section>
+
+        <section>
+            <h2>各测试趋势</h2>
+            {{if .Series}}
+            <table>
+                <thead>
+                    <tr><th>浏览器</th><th>测试</th><th>结果（从旧到新）</th><th>耗时趋势</th><th>通过率</th><th>平均耗时</th></tr>
+                </thead>
+                <tbody>
+                    {{range .Series}}
+                    <tr>
+                        <td>{{.Browser}}</td>
+                        <td>{{.Test}}</td>
+                        <td>
+                            <div class="status-strip">
+                                {{range .Points}}
+                                <div class="status-cell {{.Status | lower}}" title="{{.Time.Format "2006-01-02 15:04:05"}} {{if .Status}}{{.Status}} {{.DurationMs}}ms{{else}}未执行{{end}}"></div>
+                                {{end}}
+                            </div>
+                        </td>
+                        <td>
+                            <svg class="chart" width="{{$.Width}}" height="{{$.Height}}" viewBox="0 0 {{$.Width}} {{$.Height}}">
+                                <polyline points="{{.DurationPolyline}}"></polyline>
+                            </svg>
+                        </td>
+                        <td>{{printf "%.0f" .PassRate}}%（{{.Runs}} 次）</td>
+                        <td>{{.AvgDuration}}ms</td>
+                    </tr>
+                    {{end}}
+                </tbody>
+            </table>
+            {{else}}
+            <p class="muted">暂无历史记录</p>
+            {{end}}
+        </section>
+    </div>
+</body>
+</html>
+`
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// record 生成第 minute 分钟开始的一条历史记录
+func record(runID string, minute int, browser, test, status string) HistoryRecord {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	return HistoryRecord{RunID: runID, Time: start.Add(time.Duration(minute) * time.Minute), Browser: browser, Test: test, Status: status, DurationMs: int64(minute) * 100}
+}
+
+func TestRecentRuns(t *testing.T) {
+	// 记录的顺序与运行的顺序无关，运行时间取其中最早的一条记录
+	records := []HistoryRecord{
+		record("run-3", 30, "chromium", "登录", "Success"),
+		record("run-1", 11, "chromium", "登录", "Success"),
+		record("run-2", 20, "chromium", "登录", "Failure"),
+		record("run-1", 10, "firefox", "登录", "Success"),
+	}
+	ids := func(runs []historyRun) []string {
+		var ids []string
+		for _, run := range runs {
+			ids = append(ids, run.id)
+		}
+		return ids
+	}
+
+	all := recentRuns(records, 0)
+	if got, want := ids(all), []string{"run-1", "run-2", "run-3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recentRuns(0) = %v，应为 %v", got, want)
+	}
+	if !all[0].time.Equal(records[3].Time) {
+		t.Errorf("run-1 的时间 = %v，应为最早的记录时间 %v", all[0].time, records[3].Time)
+	}
+	if got, want := ids(recentRuns(records, 2)), []string{"run-2", "run-3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recentRuns(2) = %v，应为 %v", got, want)
+	}
+	if got := ids(recentRuns(records, 5)); len(got) != 3 {
+		t.Errorf("recentRuns(5) = %v，应返回全部 3 次运行", got)
+	}
+}
+
+func TestBuildTrends(t *testing.T) {
+	records := []HistoryRecord{
+		record("run-1", 0, "chromium", "登录", "Success"),
+		record("run-1", 1, "firefox", "登录", "Failure"),
+		record("run-2", 10, "chromium", "登录", "Failure"),
+		record("run-2", 11, "chromium", "安全区域", "Success"),
+		record("run-3", 20, "firefox", "登录", "Success"),
+		record("run-3", 21, "chromium", "登录", "Success"),
+	}
+
+	// 只保留最近两次运行，run-1 的记录不计入
+	browsers, series := BuildTrends(records, 2)
+
+	if len(browsers) != 2 || browsers[0].Browser != "chromium" || browsers[1].Browser != "firefox" {
+		t.Fatalf("browsers = %+v，应按浏览器名称排序", browsers)
+	}
+	chromium := browsers[0].Points
+	if len(chromium) != 2 || chromium[0].RunID != "run-2" || chromium[1].RunID != "run-3" {
+		t.Fatalf("chromium 的数据点 = %+v，应为 run-2、run-3", chromium)
+	}
+	if chromium[0].Total != 2 || chromium[0].Passed != 1 || chromium[0].PassRate != 50 {
+		t.Errorf("chromium run-2 = %+v，应为 2 个测试通过 1 个", chromium[0])
+	}
+	// firefox 在 run-2 中没有执行，数据点保留但没有测试
+	firefox := browsers[1].Points
+	if firefox[0].Total != 0 || firefox[1].Total != 1 || firefox[1].PassRate != 100 {
+		t.Errorf("firefox 的数据点 = %+v", firefox)
+	}
+
+	// 同名测试按浏览器分别统计
+	var keys [][2]string
+	for _, s := range series {
+		keys = append(keys, [2]string{s.Browser, s.Test})
+	}
+	want := [][2]string{{"chromium", "安全区域"}, {"chromium", "登录"}, {"firefox", "登录"}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("series = %v，应为 %v", keys, want)
+	}
+	login := series[1]
+	if login.Runs != 2 || login.PassRate != 50 || login.AvgDuration != 1550 {
+		t.Errorf("chromium 登录 = %+v，应执行 2 次，通过率 50%%，平均耗时 1550ms", login)
+	}
+	secure := series[0]
+	if secure.Runs != 1 || secure.Points[0].Status != "Success" || secure.Points[1].Status != "" {
+		t.Errorf("chromium 安全区域 = %+v，run-3 中没有执行时状态应为空", secure)
+	}
+	if firefoxLogin := series[2]; firefoxLogin.Runs != 1 || firefoxLogin.Points[1].Status != "Success" {
+		t.Errorf("firefox 登录 = %+v，不应计入 run-1 的失败", firefoxLogin)
+	}
+}
+
+func TestHistoryRecordsSkipsSkippedTests(t *testing.T) {
+	report := NewReportManager("报告")
+	report.SetBrowser("chromium")
+	report.StartTest("已执行")
+	report.LogFailure("断言失败", time.Second)
+	report.StartTest("未执行")
+	report.LogSkipped("跳过测试: 浏览器无法启动")
+	report.StartTest("执行中")
+
+	records := report.HistoryRecords("run-1")
+	if len(records) != 1 || records[0].Test != "已执行" || records[0].Status != "Failure" {
+		t.Errorf("HistoryRecords = %+v，应只包含已执行的测试", records)
+	}
+	// 没有执行的测试仍然计入退出码
+	if failures := report.BlockingFailures(); failures != 2 {
+		t.Errorf("BlockingFailures() = %d，应为 2", failures)
+	}
+}
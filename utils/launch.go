@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// BuildLaunchOptions 将浏览器配置转换为Playwright的启动选项
+func BuildLaunchOptions(browserConfig config.BrowserConfig) playwright.BrowserTypeLaunchOptions {
+	options := playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(browserConfig.Headless),
+		SlowMo:   playwright.Float(float64(browserConfig.SlowMo)),
+		Args:     browserConfig.Args,
+		Env:      browserConfig.Env,
+	}
+
+	if browserConfig.Channel != "" {
+		options.Channel = playwright.String(browserConfig.Channel)
+	}
+	if browserConfig.ExecutablePath != "" {
+		options.ExecutablePath = playwright.String(browserConfig.ExecutablePath)
+	}
+	if browserConfig.DownloadsPath != "" {
+		options.DownloadsPath = playwright.String(browserConfig.DownloadsPath)
+	}
+	if browserConfig.TimeoutMs > 0 {
+		options.Timeout = playwright.Float(float64(browserConfig.TimeoutMs))
+	}
+	if browserConfig.Proxy != nil {
+		options.Proxy = buildProxy(browserConfig.Proxy)
+	}
+
+	return options
+}
+
+// buildProxy 将代理配置转换为Playwright的代理选项
+func buildProxy(proxyConfig *config.ProxyConfig) *playwright.Proxy {
+	proxy := &playwright.Proxy{
+		Server: proxyConfig.Server,
+	}
+	if proxyConfig.Bypass != "" {
+		proxy.Bypass = playwright.String(proxyConfig.Bypass)
+	}
+	if proxyConfig.Username != "" {
+		proxy.Username = playwright.String(proxyConfig.Username)
+	}
+	if proxyConfig.Password != "" {
+		proxy.Password = playwright.String(proxyConfig.Password)
+	}
+	return proxy
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// MockUsage 记录一条模拟规则的命中情况
+type MockUsage struct {
+	Pattern string   // 路由匹配模式
+	Method  string   // 请求方法，为空表示所有方法
+	Status  int      // 响应状态码
+	Source  string   // 响应来源：body 或 fixture 路径
+	Hits    int      // 命中次数
+	URLs    []string // 命中的请求URL
+}
+
+// MockRegistry 管理附加到浏览器上下文的接口模拟规则
+type MockRegistry struct {
+	mu        sync.Mutex
+	rules     []config.MockConfig
+	usages    []MockUsage
+	unmatched []string
+}
+
+// NewMockRegistry 创建一个新的接口模拟注册表
+func NewMockRegistry(rules []config.MockConfig) *MockRegistry {
+	registry := &MockRegistry{}
+	for _, rule := range rules {
+		registry.Add(rule)
+	}
+	return registry
+}
+
+// Add 添加一条模拟规则，后添加的规则优先匹配
+func (m *MockRegistry) Add(rule config.MockConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rule.Status == 0 {
+		rule.Status = 200
+	}
+	source := "body"
+	if rule.Fixture != "" {
+		source = rule.Fixture
+	}
+	m.rules = append(m.rules, rule)
+	m.usages = append(m.usages, MockUsage{
+		Pattern: rule.URL,
+		Method:  strings.ToUpper(rule.Method),
+		Status:  rule.Status,
+		Source:  source,
+	})
+}
+
+// Len 返回模拟规则数量
+func (m *MockRegistry) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.rules)
+}
+
+// Attach 将所有模拟规则注册到浏览器上下文
+func (m *MockRegistry) Attach(context playwright.BrowserContext) error {
+	// 先注册兜底路由，Playwright会优先匹配后注册的路由，未被任何规则处理的请求最终落到这里
+	if err := context.Route("**/*", m.handleUnmatched); err != nil {
+		return fmt.Errorf("注册兜底路由失败: %w", err)
+	}
+
+	m.mu.Lock()
+	rules := make([]config.MockConfig, len(m.rules))
+	copy(rules, m.rules)
+	m.mu.Unlock()
+
+	for i, rule := range rules {
+		if err := context.Route(rule.URL, m.ruleHandler(i, rule)); err != nil {
+			return fmt.Errorf("注册模拟规则 %s 失败: %w", rule.URL, err)
+		}
+	}
+
+	return nil
+}
+
+// ruleHandler 返回处理单条模拟规则的路由函数
+func (m *MockRegistry) ruleHandler(index int, rule config.MockConfig) func(playwright.Route) {
+	return func(route playwright.Route) {
+		request := route.Request()
+		if rule.Method != "" && !strings.EqualFold(rule.Method, request.Method()) {
+			// 请求方法不匹配，交给下一个路由处理
+			route.Fallback()
+			return
+		}
+
+		m.mu.Lock()
+		m.usages[index].Hits++
+		m.usages[index].URLs = append(m.usages[index].URLs, request.URL())
+		m.mu.Unlock()
+
+		if rule.DelayMs > 0 {
+			time.Sleep(time.Duration(rule.DelayMs) * time.Millisecond)
+		}
+
+		options := playwright.RouteFulfillOptions{
+			Status:  playwright.Int(rule.Status),
+			Headers: rule.Headers,
+		}
+		if rule.ContentType != "" {
+			options.ContentType = playwright.String(rule.ContentType)
+		}
+		if rule.Fixture != "" {
+			options.Path = playwright.String(rule.Fixture)
+		} else {
+			options.Body = rule.Body
+		}
+
+		if err := route.Fulfill(options); err != nil {
+			fmt.Printf("模拟响应 %s %s 失败: %v\n", request.Method(), request.URL(), err)
+		}
+	}
+}
+
+// handleUnmatched 记录未被模拟规则处理的请求并放行
+func (m *MockRegistry) handleUnmatched(route playwright.Route) {
+	request := route.Request()
+	entry := fmt.Sprintf("%s %s", request.Method(), request.URL())
+
+	m.mu.Lock()
+	m.unmatched = append(m.unmatched, entry)
+	m.mu.Unlock()
+
+	fmt.Printf("未匹配模拟规则的请求: %s\n", entry)
+	route.Fallback()
+}
+
+// Usages 返回所有模拟规则的命中情况
+func (m *MockRegistry) Usages() []MockUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usages := make([]MockUsage, len(m.usages))
+	for i, usage := range m.usages {
+		usage.URLs = append([]string(nil), usage.URLs...)
+		usages[i] = usage
+	}
+	return usages
+}
+
+// Unmatched 返回未匹配任何模拟规则的请求
+func (m *MockRegistry) Unmatched() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.unmatched...)
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// NetworkEntry 表示测试期间发出的一个网络请求
+type NetworkEntry struct {
+	Method       string        // 请求方法
+	URL          string        // 请求URL
+	ResourceType string        // 资源类型，如 document、script、xhr
+	Status       int           // 响应状态码，请求失败时为0
+	Failure      string        // 请求失败原因
+	StartTime    time.Time     // 请求开始时间
+	Duration     time.Duration // 请求耗时
+	Size         int           // 响应体大小（字节），未知时为-1
+}
+
+// Failed 判断请求是否失败（网络错误或4xx/5xx）
+func (n NetworkEntry) Failed() bool {
+	return n.Failure != "" || n.Status >= 400
+}
+
+// DurationMs 返回以毫秒为单位的请求耗时，便于报告排序
+func (n NetworkEntry) DurationMs() int64 {
+	return n.Duration.Milliseconds()
+}
+
+// NetworkWatcher 订阅页面的请求事件，记录测试期间的网络活动
+type NetworkWatcher struct {
+	mu      sync.Mutex
+	entries []NetworkEntry
+	pending map[playwright.Request]int
+}
+
+// WatchNetwork 开始监听页面的request、response、requestfinished和requestfailed事件
+func WatchNetwork(page playwright.Page) *NetworkWatcher {
+	watcher := &NetworkWatcher{
+		pending: make(map[playwright.Request]int),
+	}
+
+	// 事件在Playwright的消息分发goroutine中同步触发，处理函数中不能调用需要等待响应的接口
+	page.OnRequest(watcher.onRequest)
+	page.OnResponse(watcher.onResponse)
+	page.OnRequestFinished(watcher.onRequestFinished)
+	page.OnRequestFailed(watcher.onRequestFailed)
+	return watcher
+}
+
+// onRequest 记录新请求
+func (w *NetworkWatcher) onRequest(request playwright.Request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.entries = append(w.entries, NetworkEntry{
+		Method:       request.Method(),
+		URL:          request.URL(),
+		ResourceType: request.ResourceType(),
+		StartTime:    time.Now(),
+		Size:         -1,
+	})
+	w.pending[request] = len(w.entries) - 1
+}
+
+// onResponse 记录响应状态码和大小
+func (w *NetworkWatcher) onResponse(response playwright.Response) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index, ok := w.pending[response.Request()]
+	if !ok {
+		return
+	}
+	w.entries[index].Status = response.Status()
+	if length, err := strconv.Atoi(response.Headers()["content-length"]); err == nil {
+		w.entries[index].Size = length
+	}
+}
+
+// onRequestFinished 记录请求耗时
+func (w *NetworkWatcher) onRequestFinished(request playwright.Request) {
+	w.finish(request, "")
+}
+
+// onRequestFailed 记录请求失败原因
+func (w *NetworkWatcher) onRequestFailed(request playwright.Request) {
+	failure := "请求失败"
+	if err := request.Failure(); err != nil {
+		failure = err.Error()
+	}
+	w.finish(request, failure)
+}
+
+// finish 结束请求的记录
+func (w *NetworkWatcher) finish(request playwright.Request, failure string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index, ok := w.pending[request]
+	if !ok {
+		return
+	}
+	delete(w.pending, request)
+
+	entry := &w.entries[index]
+	entry.Failure = failure
+	// 优先使用浏览器提供的时间数据，ResponseEnd为相对请求开始的毫秒数
+	if timing := request.Timing(); timing != nil && timing.ResponseEnd > 0 {
+		entry.Duration = time.Duration(timing.ResponseEnd * float64(time.Millisecond))
+	} else {
+		entry.Duration = time.Since(entry.StartTime)
+	}
+}
+
+// Entries 返回记录的所有请求
+func (w *NetworkWatcher) Entries() []NetworkEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]NetworkEntry(nil), w.entries...)
+}
+
+// SameOriginHTTPErrors 返回与origin同源且响应为4xx/5xx的请求
+func (w *NetworkWatcher) SameOriginHTTPErrors(origin string) []NetworkEntry {
+	var httpErrors []NetworkEntry
+	for _, entry := range w.Entries() {
+		if entry.Status >= 400 && SameOrigin(entry.URL, origin) {
+			httpErrors = append(httpErrors, entry)
+		}
+	}
+	return httpErrors
+}
+
+// SameOrigin 判断两个URL是否同源（协议、主机和端口都相同）
+func SameOrigin(a, b string) bool {
+	urlA, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	urlB, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return urlA.Scheme == urlB.Scheme && urlA.Host == urlB.Host
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// PanicError 步骤或测试中发生的panic，保留panic值和发生panic时的调用栈
+type PanicError struct {
+	Value any    // panic的值
+	Stack string // 发生panic时的调用栈
+}
+
+// Error 返回panic说明
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// NewPanicError 根据recover得到的值创建PanicError，值已经是PanicError时直接返回以保留最初的调用栈
+func NewPanicError(recovered any) *PanicError {
+	if panicErr, ok := recovered.(*PanicError); ok {
+		return panicErr
+	}
+	return &PanicError{Value: recovered, Stack: string(debug.Stack())}
+}
+
+// CatchPanic 执行fn，fn中发生的panic以 *PanicError 返回
+func CatchPanic(fn func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = NewPanicError(recovered)
+		}
+	}()
+	return fn()
+}
+
+// PanicInfo 测试中发生的panic及当时的页面状态
+type PanicInfo struct {
+	Value      string           // panic的值
+	Stack      string           // 发生panic时的调用栈
+	Screenshot string           // 页面截图，没有页面或截图失败时为空
+	Evidence   *FailureEvidence // 页面的URL、标题、HTML和无障碍树快照，没有页面时为nil
+}
+
+// LogPanic 标记当前测试因panic失败：记录panic值和调用栈，保存当时的页面截图和页面状态，
+// 仍在执行的步骤标记为失败。panic所在的步骤已保存页面状态时直接使用，不再重复截图。测试已超时时只记录panic信息
+func (r *ReportManager) LogPanic(panicErr *PanicError) {
+	r.mu.Lock()
+	r.stopStepTimers()
+	if r.currentTest == nil {
+		r.mu.Unlock()
+		return
+	}
+	test := r.currentTest
+	frames := r.steps
+	name := "panic"
+	if len(frames) > 0 {
+		name = frames[len(frames)-1].step.Name
+	}
+	if test.Status != "Timeout" {
+		test.Status = "Failure"
+		test.Message = fmt.Sprintf("测试发生panic: %v", panicErr.Value)
+		test.EndTime = time.Now()
+		test.Duration = test.EndTime.Sub(test.StartTime)
+	}
+	info := &PanicInfo{
+		Value: fmt.Sprint(panicErr.Value),
+		Stack: panicErr.Stack,
+	}
+	if step := panicStep(test.Steps, panicErr); step != nil && step.Evidence != nil {
+		info.Screenshot = step.Screenshot
+		info.Evidence = step.Evidence
+	}
+	r.steps = nil
+	r.mu.Unlock()
+
+	// 截图和保存页面状态需要等待Playwright响应，不能持有锁；无论截图模式如何都保存
+	if info.Evidence == nil {
+		page, prefix := r.reserveFrame(name, "panic")
+		info.Screenshot = r.saveFrame(page, name, "panic", prefix)
+		info.Evidence = r.captureEvidence(page, name, prefix)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	test.Panic = info
+	for _, frame := range frames {
+		step := frame.step
+		step.Status = "Failure"
+		step.Message = "步骤发生panic"
+		step.Error = panicErr
+		step.Duration = time.Since(step.Timestamp)
+	}
+	if len(frames) > 0 {
+		frames[len(frames)-1].step.Screenshot = info.Screenshot
+		frames[len(frames)-1].step.Evidence = info.Evidence
+	}
+}
+
+// panicStep 返回因 panicErr 失败的最内层步骤，没有时返回nil
+func panicStep(steps []TestStep, panicErr *PanicError) *TestStep {
+	for i := len(steps) - 1; i >= 0; i-- {
+		var stepPanic *PanicError
+		if !errors.As(steps[i].Error, &stepPanic) || stepPanic != panicErr {
+			continue
+		}
+		if inner := panicStep(steps[i].Steps, panicErr); inner != nil {
+			return inner
+		}
+		return &steps[i]
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// performanceBinding 页面中用于上报性能指标的函数名
+const performanceBinding = "__reportPerformance"
+
+// performanceInitScript 在每个文档加载前注册观察器，记录浏览器只通过观察器提供的LCP；
+// 主文档的 load 事件结束后通过 performanceBinding 上报该文档的指标，同一步骤中的多次导航都会被记录
+const performanceInitScript = `(() => {
+	window.__perfMetrics = { lcp: 0 };
+	try {
+		new PerformanceObserver(list => {
+			const entries = list.getEntries();
+			const last = entries[entries.length - 1];
+			if (last) {
+				window.__perfMetrics.lcp = last.renderTime || last.startTime;
+			}
+		}).observe({ type: 'largest-contentful-paint', buffered: true });
+	} catch (e) {}
+	if (window.top !== window) {
+		return;
+	}
+	window.addEventListener('load', () => {
+		// loadEventEnd 在 load 事件处理结束后才有值
+		setTimeout(() => {
+			const report = window.` + performanceBinding + `;
+			const metrics = (` + performanceCollectScript + `)();
+			if (typeof report === 'function' && metrics) {
+				report(metrics);
+			}
+		}, 0);
+	});
+})();`
+
+// performanceCollectScript 读取当前文档的导航计时和绘制指标，load 事件尚未结束时返回空字符串
+const performanceCollectScript = `() => {
+	const nav = performance.getEntriesByType('navigation')[0];
+	if (!nav || !nav.loadEventEnd) {
+		return '';
+	}
+	const paint = performance.getEntriesByName('first-contentful-paint')[0];
+	const observed = window.__perfMetrics || {};
+	return JSON.stringify({
+		timeOrigin: performance.timeOrigin,
+		url: location.href,
+		ttfb: nav.responseStart,
+		domContentLoaded: nav.domContentLoadedEventEnd,
+		load: nav.loadEventEnd,
+		fcp: paint ? paint.startTime : 0,
+		lcp: observed.lcp || 0,
+	});
+}`
+
+// PerformanceItem 一项性能指标及其预算
+type PerformanceItem struct {
+	Name       string  // 指标名称
+	Value      float64 // 指标值(ms)，0表示浏览器不支持或尚未产生
+	Budget     float64 // 预算(ms)，0表示不限制
+	Percent    float64 // 相对同一次导航中最大指标值的百分比，用于报告图表
+	OverBudget bool    // 是否超出预算
+}
+
+// PerformanceMetrics 一次导航的性能指标，时间均相对导航开始，单位为毫秒
+type PerformanceMetrics struct {
+	URL              string                   `json:"url"`
+	TTFB             float64                  `json:"ttfb"`             // 首字节时间
+	DOMContentLoaded float64                  `json:"domContentLoaded"` // DOMContentLoaded 事件结束时间
+	Load             float64                  `json:"load"`             // load 事件结束时间
+	FCP              float64                  `json:"fcp"`              // 首次内容绘制
+	LCP              float64                  `json:"lcp"`              // 最大内容绘制
+	Budget           config.PerformanceBudget `json:"budget"`           // 该页面的预算
+	Violations       []string                 `json:"violations"`       // 超出预算的说明
+}
+
+// Items 返回用于报告展示的各项指标
+func (m PerformanceMetrics) Items() []PerformanceItem {
+	items := []PerformanceItem{
+		{Name: "TTFB", Value: m.TTFB, Budget: m.Budget.TTFBMs},
+		{Name: "DOMContentLoaded", Value: m.DOMContentLoaded, Budget: m.Budget.DOMContentLoadedMs},
+		{Name: "Load", Value: m.Load, Budget: m.Budget.LoadMs},
+		{Name: "FCP", Value: m.FCP, Budget: m.Budget.FCPMs},
+		{Name: "LCP", Value: m.LCP, Budget: m.Budget.LCPMs},
+	}
+
+	maxValue := 0.0
+	for _, item := range items {
+		maxValue = max(maxValue, item.Value, item.Budget)
+	}
+	for i := range items {
+		if maxValue > 0 {
+			items[i].Percent = items[i].Value / maxValue * 100
+		}
+		items[i].OverBudget = items[i].Budget > 0 && items[i].Value > items[i].Budget
+	}
+	return items
+}
+
+// PerformanceMonitor 在每次导航的 load 事件结束后采集性能指标并检查预算
+// 页面上报在Playwright的goroutine中进行，与测试代码并发
+type PerformanceMonitor struct {
+	mu         sync.Mutex
+	page       playwright.Page
+	budgets    map[string]config.PerformanceBudget
+	seen       map[float64]bool                 // 已记录的文档（按timeOrigin），避免同一次导航重复记录
+	violations []string                         // 整个测试中超出预算的说明
+	onMetrics  func(metrics PerformanceMetrics) // 页面上报新导航的指标时调用
+}
+
+// WatchPerformance 为页面注册性能观察器和上报函数，必须在页面导航之前调用
+func WatchPerformance(page playwright.Page, performanceConfig config.PerformanceConfig) (*PerformanceMonitor, error) {
+	monitor := &PerformanceMonitor{
+		page:    page,
+		budgets: performanceConfig.Budgets,
+		seen:    map[float64]bool{},
+	}
+	if err := page.ExposeFunction(performanceBinding, monitor.report); err != nil {
+		return nil, fmt.Errorf("无法注册性能指标上报函数: %w", err)
+	}
+	if err := page.AddInitScript(playwright.Script{
+		Content: playwright.String(performanceInitScript),
+	}); err != nil {
+		return nil, fmt.Errorf("无法注册性能观察脚本: %w", err)
+	}
+	return monitor, nil
+}
+
+// OnMetrics 设置页面上报新导航的指标时的处理函数
+func (m *PerformanceMonitor) OnMetrics(handler func(metrics PerformanceMetrics)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onMetrics = handler
+}
+
+// report 处理页面在 load 事件结束后上报的指标
+func (m *PerformanceMonitor) report(args ...interface{}) interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	encoded, _ := args[0].(string)
+	metrics, err := m.record(encoded)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return nil
+	}
+	m.mu.Lock()
+	handler := m.onMetrics
+	m.mu.Unlock()
+	if metrics != nil && handler != nil {
+		handler(*metrics)
+	}
+	return nil
+}
+
+// Collect 采集当前文档的性能指标，已经记录过或 load 事件尚未结束时返回nil
+// 用于在步骤结束时记录页面尚未上报的导航
+func (m *PerformanceMonitor) Collect() (*PerformanceMetrics, error) {
+	raw, err := m.page.Evaluate(performanceCollectScript)
+	if err != nil {
+		return nil, fmt.Errorf("采集性能指标失败: %w", err)
+	}
+	encoded, _ := raw.(string)
+	return m.record(encoded)
+}
+
+// record 解析一次导航的指标并检查预算，已经记录过的导航返回nil
+func (m *PerformanceMonitor) record(encoded string) (*PerformanceMetrics, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	var output struct {
+		PerformanceMetrics
+		TimeOrigin float64 `json:"timeOrigin"`
+	}
+	if err := json.Unmarshal([]byte(encoded), &output); err != nil {
+		return nil, fmt.Errorf("解析性能指标失败: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen[output.TimeOrigin] {
+		return nil, nil
+	}
+	m.seen[output.TimeOrigin] = true
+
+	metrics := output.PerformanceMetrics
+	metrics.Budget = m.budgetFor(metrics.URL)
+	for _, item := range metrics.Items() {
+		if item.OverBudget {
+			metrics.Violations = append(metrics.Violations, fmt.Sprintf("%s %s %.0fms 超出预算 %.0fms", metrics.URL, item.Name, item.Value, item.Budget))
+		}
+	}
+	m.violations = append(m.violations, metrics.Violations...)
+	return &metrics, nil
+}
+
+// Violations 返回测试期间所有超出预算的说明
+func (m *PerformanceMonitor) Violations() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.violations...)
+}
+
+// budgetFor 按URL路径查找预算，没有对应路径时使用 "*" 预算
+func (m *PerformanceMonitor) budgetFor(rawURL string) config.PerformanceBudget {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		if budget, ok := m.budgets[parsed.Path]; ok {
+			return budget
+		}
+	}
+	return m.budgets["*"]
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// LocalServer 在本地启动的 playwright run-server 实例，可作为远程浏览器池的替身
+type LocalServer struct {
+	Endpoint string // ws连接地址
+	stop     func() error
+}
+
+// StartLocalServer 启动本地 Playwright 服务并等待其开始监听
+func StartLocalServer() (*LocalServer, error) {
+	port, err := freePort()
+	if err != nil {
+		return nil, fmt.Errorf("无法分配本地端口: %w", err)
+	}
+
+	driver, err := playwright.NewDriver(&playwright.RunOptions{SkipInstallBrowsers: true})
+	if err != nil {
+		return nil, fmt.Errorf("无法获取Playwright驱动: %w", err)
+	}
+
+	cmd := driver.Command("run-server", "--port", strconv.Itoa(port), "--host", "127.0.0.1")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("无法启动本地Playwright服务: %w", err)
+	}
+
+	server := &LocalServer{
+		Endpoint: fmt.Sprintf("ws://127.0.0.1:%d/", port),
+		stop: func() error {
+			if err := cmd.Process.Kill(); err != nil {
+				return err
+			}
+			cmd.Wait()
+			return nil
+		},
+	}
+
+	// 等待服务开始监听
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			server.Stop()
+			return nil, fmt.Errorf("等待本地Playwright服务启动超时: %w", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	fmt.Printf("本地Playwright服务已启动: %s\n", server.Endpoint)
+	return server, nil
+}
+
+// Stop 停止本地 Playwright 服务
+func (s *LocalServer) Stop() error {
+	if err := s.stop(); err != nil {
+		return fmt.Errorf("停止本地Playwright服务失败: %w", err)
+	}
+	return nil
+}
+
+// ConnectBrowser 按配置连接远程浏览器，endpoint为空时使用配置中的地址
+func ConnectBrowser(browserType playwright.BrowserType, browserConfig config.BrowserConfig, endpoint string) (playwright.Browser, error) {
+	connectConfig := browserConfig.Connect
+	if endpoint == "" {
+		endpoint = connectConfig.Endpoint
+	}
+
+	if connectConfig.Mode == config.ConnectModeCDP {
+		options := playwright.BrowserTypeConnectOverCDPOptions{
+			Headers: connectConfig.Headers,
+			SlowMo:  playwright.Float(float64(browserConfig.SlowMo)),
+		}
+		if connectConfig.TimeoutMs > 0 {
+			options.Timeout = playwright.Float(float64(connectConfig.TimeoutMs))
+		}
+		browser, err := browserType.ConnectOverCDP(endpoint, options)
+		if err != nil {
+			return nil, fmt.Errorf("无法通过CDP连接浏览器 %s: %w", endpoint, err)
+		}
+		return browser, nil
+	}
+
+	// Playwright服务根据请求头决定启动哪种浏览器以及使用的启动选项
+	headers, err := serverHeaders(browserConfig)
+	if err != nil {
+		return nil, err
+	}
+	options := playwright.BrowserTypeConnectOptions{
+		Headers: headers,
+		SlowMo:  playwright.Float(float64(browserConfig.SlowMo)),
+	}
+	if connectConfig.TimeoutMs > 0 {
+		options.Timeout = playwright.Float(float64(connectConfig.TimeoutMs))
+	}
+	if connectConfig.ExposeNetwork != "" {
+		options.ExposeNetwork = playwright.String(connectConfig.ExposeNetwork)
+	}
+	browser, err := browserType.Connect(endpoint, options)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接Playwright服务 %s: %w", endpoint, err)
+	}
+	return browser, nil
+}
+
+// serverHeaders 生成连接Playwright服务时的请求头
+func serverHeaders(browserConfig config.BrowserConfig) (map[string]string, error) {
+	headers := map[string]string{}
+	for key, value := range browserConfig.Connect.Headers {
+		headers[key] = value
+	}
+
+	// 只传递在远程机器上有意义的启动选项，本地路径和环境变量不传递
+	launchOptions := map[string]interface{}{
+		"headless": browserConfig.Headless,
+	}
+	if browserConfig.Channel != "" {
+		launchOptions["channel"] = browserConfig.Channel
+	}
+	if len(browserConfig.Args) > 0 {
+		launchOptions["args"] = browserConfig.Args
+	}
+	if browserConfig.Proxy != nil {
+		proxy := map[string]string{"server": browserConfig.Proxy.Server}
+		if browserConfig.Proxy.Bypass != "" {
+			proxy["bypass"] = browserConfig.Proxy.Bypass
+		}
+		if browserConfig.Proxy.Username != "" {
+			proxy["username"] = browserConfig.Proxy.Username
+			proxy["password"] = browserConfig.Proxy.Password
+		}
+		launchOptions["proxy"] = proxy
+	}
+	encoded, err := json.Marshal(launchOptions)
+	if err != nil {
+		return nil, fmt.Errorf("无法序列化启动选项: %w", err)
+	}
+
+	headers["x-playwright-browser"] = browserConfig.Type
+	headers["x-playwright-launch-options"] = string(encoded)
+	return headers, nil
+}
+
+// freePort 获取一个可用的本地端口
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
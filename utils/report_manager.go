@@ -1,42 +1,116 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
 )
 
 // TestStep 表示测试步骤
 type TestStep struct {
-	Name      string
-	Status    string // "Success", "Failure", "Running"
-	Message   string
-	Error     error
-	Timestamp time.Time
-	Screenshot string
+	Name          string
+	Status        string // "Success", "Failure", "Timeout", "Running"
+	Message       string
+	Error         error
+	Timestamp     time.Time
+	Duration      time.Duration // 步骤耗时，包括子步骤
+	Screenshot    string
+	Evidence      *FailureEvidence      // 失败或超时时的页面状态
+	Console       []ConsoleEntry        // 步骤执行期间的控制台消息和页面错误
+	Visual        []VisualResult        // 步骤中的视觉对比结果
+	Accessibility []AccessibilityResult // 步骤中的无障碍检查结果
+	Performance   []PerformanceMetrics  // 步骤中发生的导航的性能指标
+	Steps         []TestStep            // 子步骤
+}
+
+// Frame 测试过程中自动捕获的一帧截图
+type Frame struct {
+	Step  string    // 所属步骤名称
+	Label string    // 截图时机：开始、结束、失败
+	Path  string    // 截图路径
+	Time  time.Time // 截图时间
+}
+
+// 钩子的执行阶段
+const (
+	HookBeforeAll     = "BeforeAll"     // 整次运行开始前
+	HookAfterAll      = "AfterAll"      // 整次运行结束后
+	HookBeforeBrowser = "BeforeBrowser" // 每个浏览器执行测试前
+	HookAfterBrowser  = "AfterBrowser"  // 每个浏览器执行测试后
+	HookBeforeEach    = "BeforeEach"    // 每个测试开始前
+	HookAfterEach     = "AfterEach"     // 每个测试结束后
+)
+
+// HookResult 一个钩子的执行结果
+type HookResult struct {
+	Browser  string        // 浏览器条目名称，整次运行级别的钩子为空
+	Stage    string        // 执行阶段，见 Hook* 常量
+	Name     string        // 钩子名称
+	Status   string        // "Success", "Failure", "Timeout"
+	Message  string        // 失败或超时的原因
+	Time     time.Time     // 开始时间
+	Duration time.Duration // 耗时
 }
 
 // Test 表示一个测试
 type Test struct {
-	Name      string
-	Status    string // "Success", "Failure", "Running"
-	Message   string
-	StartTime time.Time
-	EndTime   time.Time
-	Duration  time.Duration
-	Steps     []TestStep
+	Browser     string // 浏览器条目名称
+	Name        string
+	Status      string // "Success", "Failure", "Timeout", "Running"
+	Message     string
+	StartTime   time.Time
+	EndTime     time.Time
+	Duration    time.Duration
+	Steps       []TestStep
+	Mocks       []MockUsage    // 接口模拟规则命中情况
+	Unmatched   []string       // 未匹配模拟规则的请求
+	HARMode     string         // HAR模式：record 或 replay
+	HARPath     string         // 录制或回放使用的HAR文件
+	Console     []ConsoleEntry // 不属于任何步骤的控制台消息和页面错误
+	Network     []NetworkEntry // 测试期间的网络请求
+	Filmstrip   []Frame        // 按时间顺序排列的步骤截图
+	Tags        []string       // 测试标签
+	Flakiness   FlakyScore     // 根据历史结果计算的不稳定程度
+	Quarantined bool           // 是否被隔离，隔离的测试失败不影响退出码
+	Hooks       []HookResult   // BeforeEach 和 AfterEach 钩子的执行结果
+	Panic       *PanicInfo     // 测试中发生的panic，没有时为nil
+	Skipped     bool           // 浏览器无法使用或前置钩子失败而没有执行，不写入历史结果
 }
 
 // ReportManager 管理测试报告
 type ReportManager struct {
-	Title     string
-	StartTime time.Time
-	Tests     []Test
+	mu          sync.Mutex // 页面事件会在其他goroutine中写入报告
+	Title       string
+	StartTime   time.Time
+	Tests       []Test
+	Launches    []BrowserLaunch // 浏览器启动记录，启动耗时不计入测试耗时
+	Hooks       []HookResult    // 浏览器级别的钩子执行结果
+	SuiteHooks  []HookResult    // 整次运行级别的钩子执行结果，每个浏览器的报告中各有一份，合并时去重
 	currentTest *Test
-	currentStep *TestStep
+	steps       []stepFrame // 正在执行的步骤，外层步骤在前
+
+	screenshotMode string              // 步骤截图模式，见 config.ScreenshotMode*
+	page           playwright.Page     // 当前测试的页面，用于自动截图
+	frameDir       string              // 当前测试的截图目录
+	frameCount     int                 // 当前测试已捕获的截图数
+	performance    *PerformanceMonitor // 当前测试的性能指标采集器
+	trendsPage     string              // 历史趋势页面路径
+	flaky          *FlakyDetector      // 不稳定测试检测器
+	browser        string              // 报告对应的浏览器条目名称
+	tagFilter      string              // 本次运行使用的标签筛选表达式
+	merged         bool                // 是否为合并多个结果文件得到的报告
+
+	stepTimeout time.Duration           // 单个步骤的超时，0表示不限制
+	cancelTest  context.CancelCauseFunc // 步骤超时时取消当前测试的context
 }
 
 // NewReportManager 创建一个新的报告管理器
@@ -50,52 +124,446 @@ func NewReportManager(title string) *ReportManager {
 
 // StartTest 开始一个新的测试
 func (r *ReportManager) StartTest(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	test := Test{
+		Browser:   r.browser,
 		Name:      name,
 		Status:    "Running",
 		StartTime: time.Now(),
 		Steps:     []TestStep{},
 	}
+	if r.flaky != nil {
+		test.Flakiness = r.flaky.Score(r.browser, name)
+		test.Quarantined = r.flaky.Quarantined(r.browser, name)
+	}
 	r.Tests = append(r.Tests, test)
 	r.currentTest = &r.Tests[len(r.Tests)-1]
+	r.stopStepTimers()
+	r.steps = nil
+	r.page = nil
+	r.frameCount = 0
+	r.performance = nil
+	r.stepTimeout = 0
+	r.cancelTest = nil
 }
 
-// StartStep 开始一个新的测试步骤
-func (r *ReportManager) StartStep(name string) {
+// WatchSteps 返回在当前测试的任一步骤超过timeout时以TimeoutError取消的context，timeout不大于0时不限制步骤耗时
+func (r *ReportManager) WatchSteps(ctx context.Context, timeout time.Duration) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stepTimeout = timeout
+	r.cancelTest = cancel
+	return ctx
+}
+
+// stepFrame 一个正在执行的步骤及其超时计时器
+type stepFrame struct {
+	step  *TestStep
+	timer *time.Timer
+}
+
+// current 返回最内层正在执行的步骤，调用方需持有锁
+func (r *ReportManager) current() *TestStep {
+	if len(r.steps) == 0 {
+		return nil
+	}
+	return r.steps[len(r.steps)-1].step
+}
+
+// currentFor 返回 owner 测试最内层正在执行的步骤，owner 已不是当前测试时返回nil；
+// owner 为nil时不检查，调用方需持有锁
+func (r *ReportManager) currentFor(owner *Test) *TestStep {
+	if owner != nil && owner != r.currentTest {
+		return nil
+	}
+	return r.current()
+}
+
+// newStepTimer 为步骤开始计时，未设置步骤超时时返回nil，调用方需持有锁
+func (r *ReportManager) newStepTimer(name string) *time.Timer {
+	if r.cancelTest == nil || r.stepTimeout <= 0 {
+		return nil
+	}
+	cancel, timeout := r.cancelTest, r.stepTimeout
+	return time.AfterFunc(timeout, func() {
+		cancel(&TimeoutError{Scope: fmt.Sprintf("步骤「%s」", name), Timeout: timeout})
+	})
+}
+
+// stopStepTimers 停止所有正在执行的步骤的计时，调用方需持有锁
+func (r *ReportManager) stopStepTimers() {
+	for _, frame := range r.steps {
+		if frame.timer != nil {
+			frame.timer.Stop()
+		}
+	}
+}
+
+// SetScreenshotMode 设置步骤截图模式：never、on-failure(默认) 或 every-step
+func (r *ReportManager) SetScreenshotMode(mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.screenshotMode = mode
+}
+
+// AttachPage 设置当前测试的页面，步骤截图保存到 dir 下以测试名称命名的子目录
+func (r *ReportManager) AttachPage(page playwright.Page, dir string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.currentTest == nil {
 		return
 	}
-	step := TestStep{
+	r.page = page
+	r.frameDir = filepath.Join(dir, sanitizeFileName(r.currentTest.Name))
+}
+
+// AttachPerformance 设置当前测试的性能指标采集器，每次导航的指标记录到正在执行的步骤，
+// 步骤结束时再采集一次尚未上报的导航
+func (r *ReportManager) AttachPerformance(monitor *PerformanceMonitor) {
+	r.mu.Lock()
+	r.performance = monitor
+	r.mu.Unlock()
+	monitor.OnMetrics(r.recordPerformance)
+}
+
+// recordPerformance 将一次导航的性能指标记录到正在执行的步骤，没有正在执行的步骤时只参与预算检查
+func (r *ReportManager) recordPerformance(metrics PerformanceMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if step := r.current(); step != nil {
+		step.Performance = append(step.Performance, metrics)
+	}
+}
+
+// StartStep 开始一个新的测试步骤，已有正在执行的步骤时作为其子步骤，every-step模式下同时截图
+func (r *ReportManager) StartStep(name string) {
+	r.startStep(nil, name)
+}
+
+// startStep 为 owner 测试开始一个新的测试步骤，返回该步骤；测试已结束或 owner 已不是当前测试时返回nil
+func (r *ReportManager) startStep(owner *Test, name string) *TestStep {
+	r.mu.Lock()
+	// 测试超时后仍在执行的测试代码不再记录步骤，也不能记录到之后的测试中
+	if r.currentTest == nil || r.currentTest.Status != "Running" || (owner != nil && owner != r.currentTest) {
+		r.mu.Unlock()
+		return nil
+	}
+	mode := r.screenshotMode
+	siblings := &r.currentTest.Steps
+	if parent := r.current(); parent != nil {
+		siblings = &parent.Steps
+	}
+	*siblings = append(*siblings, TestStep{
 		Name:      name,
 		Status:    "Running",
 		Timestamp: time.Now(),
+	})
+	step := &(*siblings)[len(*siblings)-1]
+	r.steps = append(r.steps, stepFrame{step: step, timer: r.newStepTimer(name)})
+	r.mu.Unlock()
+
+	if mode == config.ScreenshotModeEveryStep {
+		r.captureFrame(name, "开始", "start")
 	}
-	r.currentTest.Steps = append(r.currentTest.Steps, step)
-	r.currentStep = &r.currentTest.Steps[len(r.currentTest.Steps)-1]
+	return step
 }
 
-// EndStepSuccess 标记当前步骤为成功
+// running 返回最内层正在执行的步骤，step不为nil时只在它是最内层步骤时返回，调用方需持有锁
+func (r *ReportManager) running(step *TestStep) *TestStep {
+	current := r.current()
+	if step != nil && step != current {
+		return nil
+	}
+	return current
+}
+
+// finishStep 结束最内层步骤并记录耗时，调用方需持有锁
+func (r *ReportManager) finishStep(status string) {
+	frame := r.steps[len(r.steps)-1]
+	if frame.timer != nil {
+		frame.timer.Stop()
+	}
+	frame.step.Status = status
+	frame.step.Duration = time.Since(frame.step.Timestamp)
+	r.steps = r.steps[:len(r.steps)-1]
+}
+
+// EndStepSuccess 标记最内层步骤为成功，message为空时保留 SetStepMessage 设置的说明
 func (r *ReportManager) EndStepSuccess(message string) {
-	if r.currentStep == nil {
+	r.endStepSuccess(nil, message)
+}
+
+// endStepSuccess 标记步骤为成功，step为nil时为最内层步骤
+func (r *ReportManager) endStepSuccess(step *TestStep, message string) {
+	r.collectPerformance()
+
+	r.mu.Lock()
+	step = r.running(step)
+	if step == nil {
+		r.mu.Unlock()
 		return
 	}
-	r.currentStep.Status = "Success"
-	r.currentStep.Message = message
+	if message != "" {
+		step.Message = message
+	}
+	name := step.Name
+	mode := r.screenshotMode
+	r.finishStep("Success")
+	r.mu.Unlock()
+
+	if mode == config.ScreenshotModeEveryStep {
+		r.captureFrame(name, "结束", "end")
+	}
 }
 
-// EndStepFailure 标记当前步骤为失败
+// EndStepFailure 标记最内层步骤为失败，screenshot为空时使用 AttachScreenshot 附加的截图，
+// 都没有且没有已截图的失败子步骤时按截图模式自动截图；同时保存页面的URL、标题、HTML和无障碍树快照
 func (r *ReportManager) EndStepFailure(message string, err error, screenshot string) {
-	if r.currentStep == nil {
+	r.endStepFailure(nil, message, err, screenshot)
+}
+
+// endStepFailure 标记步骤为失败，step为nil时为最内层步骤
+func (r *ReportManager) endStepFailure(step *TestStep, message string, err error, screenshot string) {
+	r.collectPerformance()
+
+	r.mu.Lock()
+	step = r.running(step)
+	if step == nil {
+		r.mu.Unlock()
+		return
+	}
+	if screenshot == "" {
+		screenshot = step.Screenshot
+	}
+	// 失败的子步骤已有截图时不再重复截图
+	inherited := screenshot == "" && failedChildScreenshot(step) != ""
+	name := step.Name
+	mode := r.screenshotMode
+	r.mu.Unlock()
+
+	// 截图和保存页面状态需要等待Playwright响应，不能持有锁，否则会阻塞页面事件的记录
+	var evidence *FailureEvidence
+	if !inherited {
+		page, prefix := r.reserveFrame(name, "failure")
+		if mode != config.ScreenshotModeNever {
+			if screenshot == "" {
+				screenshot = r.saveFrame(page, name, "失败", prefix)
+			} else {
+				r.addFrame(name, "失败", screenshot)
+			}
+		}
+		evidence = r.captureEvidence(page, name, prefix)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running(step) == nil {
+		return
+	}
+	if message != "" {
+		step.Message = message
+	}
+	step.Error = err
+	step.Screenshot = screenshot
+	step.Evidence = evidence
+	r.finishStep("Failure")
+}
+
+// failedChildScreenshot 返回最后一个失败或超时的子步骤的截图
+func failedChildScreenshot(step *TestStep) string {
+	for i := len(step.Steps) - 1; i >= 0; i-- {
+		child := step.Steps[i]
+		if child.Status != "Failure" && child.Status != "Timeout" {
+			continue
+		}
+		if child.Screenshot != "" {
+			return child.Screenshot
+		}
+		return failedChildScreenshot(&child)
+	}
+	return ""
+}
+
+// collectPerformance 采集页面尚未上报的导航的性能指标，记录到正在执行的步骤
+func (r *ReportManager) collectPerformance() {
+	r.mu.Lock()
+	monitor := r.performance
+	r.mu.Unlock()
+	if monitor == nil {
+		return
+	}
+
+	// 采集需要等待Playwright响应，不能持有锁
+	metrics, err := monitor.Collect()
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return
+	}
+	if metrics != nil {
+		r.recordPerformance(*metrics)
+	}
+}
+
+// reserveFrame 为一次截图或失败现场分配编号，返回当前页面和不含扩展名的文件路径前缀，
+// 同一次失败的截图、HTML和无障碍树快照共用一个前缀；没有页面时返回nil
+func (r *ReportManager) reserveFrame(stepName, suffix string) (playwright.Page, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.page == nil {
+		return nil, ""
+	}
+	r.frameCount++
+	return r.page, filepath.Join(r.frameDir, fmt.Sprintf("%02d-%s-%s", r.frameCount, sanitizeFileName(stepName), suffix))
+}
+
+// captureFrame 对当前页面截图并加入时间线，返回截图路径，没有页面或截图失败时返回空字符串
+func (r *ReportManager) captureFrame(stepName, label, suffix string) string {
+	page, prefix := r.reserveFrame(stepName, suffix)
+	return r.saveFrame(page, stepName, label, prefix)
+}
+
+// saveFrame 将页面截图保存为 prefix.png 并加入时间线，返回截图路径
+func (r *ReportManager) saveFrame(page playwright.Page, stepName, label, prefix string) string {
+	if page == nil {
+		return ""
+	}
+	path := prefix + ".png"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Printf("无法创建步骤截图目录: %v\n", err)
+		return ""
+	}
+	if err := TakeScreenshot(page, path); err != nil {
+		fmt.Printf("步骤 %s 截图失败: %v\n", stepName, err)
+		return ""
+	}
+	r.addFrame(stepName, label, path)
+	return path
+}
+
+// saveFrameWithin 与 saveFrame 相同，但最多等待 evidenceTimeout，页面无响应时放弃截图，
+// 避免超时后迟迟不能关闭浏览器上下文
+func (r *ReportManager) saveFrameWithin(page playwright.Page, stepName, label, prefix string) string {
+	if page == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), evidenceTimeout)
+	defer cancel()
+	var path string
+	if _, _, err := RunWithContext(ctx, func() bool {
+		path = r.saveFrame(page, stepName, label, prefix)
+		return true
+	}); err != nil {
+		fmt.Printf("步骤 %s 截图超时\n", stepName)
+		return ""
+	}
+	return path
+}
+
+// addFrame 将截图加入当前测试的时间线
+func (r *ReportManager) addFrame(stepName, label, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.currentTest == nil {
+		return
+	}
+	r.currentTest.Filmstrip = append(r.currentTest.Filmstrip, Frame{
+		Step:  stepName,
+		Label: label,
+		Path:  path,
+		Time:  time.Now(),
+	})
+}
+
+// RecordMocks 记录当前测试的接口模拟命中情况
+func (r *ReportManager) RecordMocks(usages []MockUsage, unmatched []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.currentTest == nil {
+		return
+	}
+	r.currentTest.Mocks = usages
+	r.currentTest.Unmatched = unmatched
+}
+
+// RecordConsole 将控制台消息记录到正在执行的步骤，没有正在执行的步骤时记录到当前测试
+func (r *ReportManager) RecordConsole(entry ConsoleEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if step := r.current(); step != nil {
+		step.Console = append(step.Console, entry)
+		return
+	}
+	if r.currentTest != nil {
+		r.currentTest.Console = append(r.currentTest.Console, entry)
+	}
+}
+
+// RecordNetwork 记录当前测试的网络请求
+func (r *ReportManager) RecordNetwork(entries []NetworkEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.currentTest == nil {
+		return
+	}
+	r.currentTest.Network = entries
+}
+
+// RecordBrowserLaunches 记录浏览器启动情况
+func (r *ReportManager) RecordBrowserLaunches(launches []BrowserLaunch) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Launches = launches
+}
+
+// RecordVisual 将视觉对比结果记录到当前步骤
+func (r *ReportManager) RecordVisual(result VisualResult) {
+	r.recordVisual(nil, result)
+}
+
+// recordVisual 将视觉对比结果记录到 owner 测试的当前步骤
+func (r *ReportManager) recordVisual(owner *Test, result VisualResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	step := r.currentFor(owner)
+	if step == nil {
+		return
+	}
+	step.Visual = append(step.Visual, result)
+}
+
+// RecordAccessibility 将无障碍检查结果记录到当前步骤
+func (r *ReportManager) RecordAccessibility(result AccessibilityResult) {
+	r.recordAccessibility(nil, result)
+}
+
+// recordAccessibility 将无障碍检查结果记录到 owner 测试的当前步骤
+func (r *ReportManager) recordAccessibility(owner *Test, result AccessibilityResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	step := r.currentFor(owner)
+	if step == nil {
+		return
+	}
+	step.Accessibility = append(step.Accessibility, result)
+}
+
+// RecordHAR 记录当前测试录制或回放的HAR文件
+func (r *ReportManager) RecordHAR(mode, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.currentTest == nil {
 		return
 	}
-	r.currentStep.Status = "Failure"
-	r.currentStep.Message = message
-	r.currentStep.Error = err
-	r.currentStep.Screenshot = screenshot
+	r.currentTest.HARMode = mode
+	r.currentTest.HARPath = path
 }
 
 // LogSuccess 标记当前测试为成功
 func (r *ReportManager) LogSuccess(message string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.currentTest == nil {
 		return
 	}
@@ -107,6 +575,8 @@ func (r *ReportManager) LogSuccess(message string, duration time.Duration) {
 
 // LogFailure 标记当前测试为失败
 func (r *ReportManager) LogFailure(message string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.currentTest == nil {
 		return
 	}
@@ -116,17 +586,205 @@ func (r *ReportManager) LogFailure(message string, duration time.Duration) {
 	r.currentTest.Duration = duration
 }
 
+// LogSkipped 将当前测试标记为没有执行，记录为失败，但不写入历史结果
+func (r *ReportManager) LogSkipped(message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.currentTest == nil {
+		return
+	}
+	r.currentTest.Status = "Failure"
+	r.currentTest.Message = message
+	r.currentTest.EndTime = time.Now()
+	r.currentTest.Skipped = true
+}
+
+// LogTimeout 标记当前测试为超时：正在执行的步骤（包括外层步骤）标记为超时，最内层步骤按截图模式截图；
+// 没有正在执行的步骤时追加一个超时步骤。之后仍在执行的测试代码不会再修改该测试的步骤
+func (r *ReportManager) LogTimeout(err error, duration time.Duration) {
+	r.mu.Lock()
+	r.stopStepTimers()
+	if r.currentTest == nil {
+		r.mu.Unlock()
+		return
+	}
+	test := r.currentTest
+	frames := r.steps
+	name := "测试超时"
+	if len(frames) > 0 {
+		name = frames[len(frames)-1].step.Name
+	}
+	test.Status = "Timeout"
+	test.Message = err.Error()
+	test.EndTime = time.Now()
+	test.Duration = duration
+	r.steps = nil
+	mode := r.screenshotMode
+	r.mu.Unlock()
+
+	// 截图和保存页面状态需要等待Playwright响应，不能持有锁
+	page, prefix := r.reserveFrame(name, "timeout")
+	screenshot := ""
+	if mode != config.ScreenshotModeNever {
+		screenshot = r.saveFrameWithin(page, name, "超时", prefix)
+	}
+	evidence := r.captureEvidence(page, name, prefix)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(frames) == 0 {
+		test.Steps = append(test.Steps, TestStep{Name: name, Timestamp: time.Now()})
+		frames = []stepFrame{{step: &test.Steps[len(test.Steps)-1]}}
+	}
+	for i, frame := range frames {
+		step := frame.step
+		step.Status = "Timeout"
+		step.Duration = time.Since(step.Timestamp)
+		if i < len(frames)-1 {
+			step.Message = "子步骤执行超时"
+			continue
+		}
+		step.Message = "步骤执行超时"
+		step.Error = err
+		step.Screenshot = screenshot
+		step.Evidence = evidence
+	}
+}
+
+// RecordHooks 记录钩子的执行结果：BeforeEach 和 AfterEach 记录到当前测试，失败或超时时当前测试也标记为失败；
+// BeforeAll 和 AfterAll 不属于某个浏览器，记录到 SuiteHooks；其他阶段的钩子记录到报告
+func (r *ReportManager) RecordHooks(results ...HookResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, result := range results {
+		if result.Stage == HookBeforeAll || result.Stage == HookAfterAll {
+			r.SuiteHooks = append(r.SuiteHooks, result)
+			continue
+		}
+		result.Browser = r.browser
+		if result.Stage != HookBeforeEach && result.Stage != HookAfterEach {
+			r.Hooks = append(r.Hooks, result)
+			continue
+		}
+		if r.currentTest == nil {
+			continue
+		}
+		r.currentTest.Hooks = append(r.currentTest.Hooks, result)
+		if result.Status != "Success" && (r.currentTest.Status == "Success" || r.currentTest.Status == "Running") {
+			r.currentTest.Status = "Failure"
+			r.currentTest.Message = fmt.Sprintf("%s 钩子「%s」失败: %s", result.Stage, result.Name, result.Message)
+		}
+	}
+}
+
+// SetBrowser 设置报告对应的浏览器条目名称
+func (r *ReportManager) SetBrowser(browser string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.browser = browser
+}
+
+// Browser 返回报告对应的浏览器条目名称
+func (r *ReportManager) Browser() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.browser
+}
+
+// SetFlakyDetector 设置不稳定测试检测器
+func (r *ReportManager) SetFlakyDetector(detector *FlakyDetector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flaky = detector
+}
+
+// BlockingFailures 返回影响退出码的失败和超时测试数与失败的钩子数，隔离的测试不计入
+// 多个报告共享 SuiteHooks，需要先用 MergeResults 合并再统计，否则整次运行级别的钩子会被重复计数
+func (r *ReportManager) BlockingFailures() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failures := 0
+	for _, hook := range r.SuiteHooks {
+		if hook.Status != "Success" {
+			failures++
+		}
+	}
+	for _, hook := range r.Hooks {
+		if hook.Status != "Success" {
+			failures++
+		}
+	}
+	for _, test := range r.Tests {
+		if (test.Status == "Failure" || test.Status == "Timeout") && !test.Quarantined {
+			failures++
+		}
+	}
+	return failures
+}
+
+// SetTagFilter 设置本次运行使用的标签筛选表达式，用于报告展示
+func (r *ReportManager) SetTagFilter(expression string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tagFilter = expression
+}
+
+// RecordTags 记录当前测试的标签
+func (r *ReportManager) RecordTags(tags []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.currentTest == nil {
+		return
+	}
+	r.currentTest.Tags = tags
+}
+
+// SetTrendsPage 设置历史趋势页面路径，报告中会链接到该页面
+func (r *ReportManager) SetTrendsPage(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.trendsPage = path
+}
+
+// HistoryRecords 将本报告中已结束的测试转换为历史记录
+func (r *ReportManager) HistoryRecords(runID string) []HistoryRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []HistoryRecord
+	for _, test := range r.Tests {
+		// 没有执行的测试不反映测试本身的稳定性，不计入趋势和不稳定检测
+		if test.Status == "Running" || test.Skipped {
+			continue
+		}
+		records = append(records, HistoryRecord{
+			RunID:      runID,
+			Time:       test.StartTime,
+			Browser:    test.Browser,
+			Test:       test.Name,
+			Status:     test.Status,
+			DurationMs: test.Duration.Milliseconds(),
+		})
+	}
+	return records
+}
+
 // GenerateReport 生成HTML测试报告
 func (r *ReportManager) GenerateReport() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	// 创建报告目录
 	reportDir := "./reports"
 	if _, err := os.Stat(reportDir); os.IsNotExist(err) {
 		os.MkdirAll(reportDir, 0755)
 	}
 
-	// 生成报告文件名
+	// 生成报告文件名，各浏览器的报告在同一时刻生成，文件名中加入浏览器名称避免相互覆盖
 	timestamp := time.Now().Format("20060102-150405")
-	reportPath := filepath.Join(reportDir, fmt.Sprintf("report-%s.html", timestamp))
+	fileName := fmt.Sprintf("report-%s.html", timestamp)
+	if r.browser != "" {
+		fileName = fmt.Sprintf("report-%s-%s.html", sanitizeFileName(r.browser), timestamp)
+	}
+	reportPath := filepath.Join(reportDir, fileName)
 
 	// 创建报告文件
 	file, err := os.Create(reportPath)
@@ -138,6 +796,7 @@ func (r *ReportManager) GenerateReport() (string, error) {
 	// 准备报告模板
 	funcMap := template.FuncMap{
 		"lower": strings.ToLower,
+		"join":  strings.Join,
 	}
 	tmpl := template.Must(template.New("report").Funcs(funcMap).Parse(reportTemplate))
 
@@ -145,35 +804,70 @@ func (r *ReportManager) GenerateReport() (string, error) {
 	totalSteps := 0
 	passedSteps := 0
 	failedSteps := 0
+	timedOutSteps := 0
 
+	tagSet := map[string]bool{}
 	for _, test := range r.Tests {
-		for _, step := range test.Steps {
+		for _, tag := range test.Tags {
+			tagSet[tag] = true
+		}
+		walkSteps(test.Steps, 0, func(step TestStep, depth int) {
 			totalSteps++
 			if step.Status == "Success" {
 				passedSteps++
 			} else if step.Status == "Failure" {
 				failedSteps++
+			} else if step.Status == "Timeout" {
+				timedOutSteps++
 			}
+		})
+	}
+
+	allTags := make([]string, 0, len(tagSet))
+	for tag := range tagSet {
+		allTags = append(allTags, tag)
+	}
+	sort.Strings(allTags)
+
+	// 趋势页面链接相对报告目录
+	trendsLink := ""
+	if r.trendsPage != "" {
+		if link, err := filepath.Rel(reportDir, r.trendsPage); err == nil {
+			trendsLink = filepath.ToSlash(link)
 		}
 	}
 
 	// 准备模板数据
 	data := struct {
-		Title       string
-		StartTime   time.Time
-		Tests       []Test
-		TotalTests  int
-		TotalSteps  int
-		PassedSteps int
-		FailedSteps int
+		Title         string
+		TrendsLink    string
+		StartTime     time.Time
+		Tests         []Test
+		Tags          []string
+		TagFilter     string
+		Merged        bool
+		Launches      []BrowserLaunch
+		Hooks         []HookResult
+		TotalTests    int
+		TotalSteps    int
+		PassedSteps   int
+		FailedSteps   int
+		TimedOutSteps int
 	}{
-		Title:       r.Title,
-		StartTime:   r.StartTime,
-		Tests:       r.Tests,
-		TotalTests:  len(r.Tests),
-		TotalSteps:  totalSteps,
-		PassedSteps: passedSteps,
-		FailedSteps: failedSteps,
+		Title:         r.Title,
+		TrendsLink:    trendsLink,
+		StartTime:     r.StartTime,
+		Tests:         r.Tests,
+		Tags:          allTags,
+		TagFilter:     r.tagFilter,
+		Merged:        r.merged,
+		Launches:      r.Launches,
+		Hooks:         append(append([]HookResult{}, r.SuiteHooks...), r.Hooks...),
+		TotalTests:    len(r.Tests),
+		TotalSteps:    totalSteps,
+		PassedSteps:   passedSteps,
+		FailedSteps:   failedSteps,
+		TimedOutSteps: timedOutSteps,
 	}
 
 	// 执行模板
@@ -196,6 +890,7 @@ const reportTemplate = `
         :root {
             --success-color: #28a745;
             --failure-color: #dc3545;
+            --timeout-color: #fd7e14;
             --running-color: #17a2b8;
             --neutral-color: #6c757d;
             --light-bg: #f8f9fa;
@@ -322,6 +1017,11 @@ const reportTemplate = `
             color: var(--failure-color);
         }
         
+        .stat-box.timed-out {
+            background-color: rgba(253, 126, 20, 0.1);
+            color: var(--timeout-color);
+        }
+        
         .progress-container {
             margin: 15px 0;
             background-color: #e9ecef;
@@ -373,6 +1073,70 @@ const reportTemplate = `
             font-size: 0.9em;
         }
         
+        .tag-filter {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 8px;
+            margin-bottom: 15px;
+        }
+        
+        .tag-filter-button {
+            padding: 4px 12px;
+            border: 1px solid var(--running-color);
+            border-radius: 14px;
+            background-color: white;
+            color: var(--running-color);
+            cursor: pointer;
+        }
+        
+        .tag-filter-button.active {
+            background-color: var(--running-color);
+            color: white;
+        }
+        
+        .browser-badge {
+            display: inline-block;
+            margin-left: 8px;
+            padding: 2px 8px;
+            border-radius: 10px;
+            font-size: 12px;
+            font-weight: normal;
+            color: white;
+            background-color: var(--running-color);
+            vertical-align: middle;
+        }
+        
+        .tag-badge {
+            display: inline-block;
+            margin-left: 8px;
+            padding: 2px 8px;
+            border-radius: 10px;
+            font-size: 12px;
+            font-weight: normal;
+            background-color: var(--light-bg);
+            border: 1px solid #ddd;
+            vertical-align: middle;
+        }
+        
+        .flaky-badge, .quarantine-badge {
+            display: inline-block;
+            margin-left: 8px;
+            padding: 2px 8px;
+            border-radius: 10px;
+            font-size: 12px;
+            font-weight: normal;
+            color: white;
+            vertical-align: middle;
+        }
+        
+        .flaky-badge {
+            background-color: #fd7e14;
+        }
+        
+        .quarantine-badge {
+            background-color: var(--neutral-color);
+        }
+        
         .test-info {
             display: flex;
             flex-wrap: wrap;
@@ -423,6 +1187,12 @@ const reportTemplate = `
             margin-top: 10px;
         }
         
+        .sub-steps {
+            margin-top: 10px;
+            padding-left: 15px;
+            border-left: 2px solid #dee2e6;
+        }
+        
         .error {
             background-color: rgba(220, 53, 69, 0.1);
             color: var(--failure-color);
@@ -452,6 +1222,157 @@ const reportTemplate = `
             transform: scale(1.02);
         }
         
+        .filmstrip-viewer {
+            text-align: center;
+        }
+        
+        .filmstrip-current {
+            max-height: 400px;
+        }
+        
+        .filmstrip-caption {
+            margin: 8px 0;
+            color: #666;
+            font-size: 14px;
+        }
+        
+        .filmstrip-slider {
+            width: 100%;
+        }
+        
+        .filmstrip-frames {
+            display: flex;
+            gap: 10px;
+            overflow-x: auto;
+            padding: 10px 0;
+        }
+        
+        .filmstrip-frame {
+            flex: 0 0 auto;
+            margin: 0;
+            width: 120px;
+            text-align: center;
+            font-size: 12px;
+            cursor: pointer;
+            opacity: 0.6;
+            transition: var(--transition);
+        }
+        
+        .filmstrip-frame img {
+            width: 100%;
+            border: 2px solid transparent;
+            border-radius: var(--border-radius);
+        }
+        
+        .filmstrip-frame.active {
+            opacity: 1;
+        }
+        
+        .filmstrip-frame.active img {
+            border-color: var(--running-color);
+        }
+        
+        .perf-result {
+            margin-top: 15px;
+            padding: 10px;
+            border-radius: var(--border-radius);
+        }
+        
+        .perf-row {
+            display: flex;
+            align-items: center;
+            gap: 10px;
+            margin: 4px 0;
+            font-size: 14px;
+        }
+        
+        .perf-name {
+            width: 140px;
+        }
+        
+        .perf-track {
+            flex: 1;
+            height: 14px;
+            background-color: var(--light-bg);
+            border-radius: 7px;
+            overflow: hidden;
+        }
+        
+        .perf-bar {
+            height: 100%;
+            background-color: var(--running-color);
+        }
+        
+        .perf-row.over-budget .perf-bar {
+            background-color: var(--failure-color);
+        }
+        
+        .perf-row.over-budget .perf-value {
+            color: var(--failure-color);
+            font-weight: bold;
+        }
+        
+        .perf-value {
+            width: 160px;
+            text-align: right;
+        }
+        
+        .a11y-result {
+            margin-top: 15px;
+            padding: 10px;
+            border-radius: var(--border-radius);
+        }
+        
+        .a11y-node {
+            margin: 2px 0;
+        }
+        
+        .impact-badge {
+            display: inline-block;
+            padding: 2px 8px;
+            border-radius: 10px;
+            color: white;
+            font-size: 12px;
+        }
+        
+        .impact-critical {
+            background-color: #721c24;
+        }
+        
+        .impact-serious {
+            background-color: var(--failure-color);
+        }
+        
+        .impact-moderate {
+            background-color: #fd7e14;
+        }
+        
+        .impact-minor {
+            background-color: var(--neutral-color);
+        }
+        
+        .visual-result {
+            margin-top: 15px;
+            padding: 10px;
+            border-radius: var(--border-radius);
+        }
+        
+        .visual-images {
+            display: flex;
+            gap: 10px;
+            margin-top: 10px;
+        }
+        
+        .visual-images figure {
+            flex: 1;
+            text-align: center;
+        }
+        
+        .visual-images figcaption {
+            color: var(--neutral-color);
+            font-size: 0.9em;
+        }
+        
         .screenshot-modal {
             display: none;
             position: fixed;
@@ -501,6 +1422,11 @@ const reportTemplate = `
             border-left: 4px solid var(--failure-color);
         }
         
+        .timeout {
+            background-color: rgba(253, 126, 20, 0.1);
+            border-left: 4px solid var(--timeout-color);
+        }
+        
         .running {
             background-color: rgba(23, 162, 184, 0.1);
             border-left: 4px solid var(--running-color);
@@ -516,11 +1442,120 @@ const reportTemplate = `
             color: white;
         }
         
+        .hook-status {
+            padding: 2px 8px;
+            border-radius: var(--border-radius);
+            font-size: 0.9em;
+        }
+        
+        .status-timeout {
+            background-color: var(--timeout-color);
+            color: white;
+        }
+        
         .status-running {
             background-color: var(--running-color);
             color: white;
         }
         
+        .data-table {
+            width: 100%;
+            border-collapse: collapse;
+            margin-top: 10px;
+            font-size: 0.9em;
+        }
+        
+        .data-table th, .data-table td {
+            padding: 6px 10px;
+            border-bottom: 1px solid #eee;
+            text-align: left;
+            word-break: break-all;
+        }
+        
+        .data-table th {
+            background-color: var(--light-bg);
+        }
+        
+        .sortable th {
+            cursor: pointer;
+            user-select: none;
+        }
+        
+        .sortable th.sort-asc:after {
+            content: ' ▲';
+        }
+        
+        .sortable th.sort-desc:after {
+            content: ' ▼';
+        }
+        
+        .request-failed {
+            color: var(--failure-color);
+        }
+        
+        .unused {
+            color: var(--neutral-color);
+        }
+        
+        .console-log {
+            margin-top: 10px;
+        }
+        
+        .console-entry {
+            padding: 6px 10px;
+            margin: 5px 0;
+            border-left: 3px solid var(--neutral-color);
+            background-color: var(--light-bg);
+            font-size: 0.9em;
+        }
+        
+        .console-error, .console-pageerror {
+            border-left-color: var(--failure-color);
+        }
+        
+        .console-warning {
+            border-left-color: #ffc107;
+        }
+        
+        .console-level {
+            font-weight: bold;
+            text-transform: uppercase;
+            margin-right: 8px;
+        }
+        
+        .console-location {
+            color: var(--neutral-color);
+            margin-left: 8px;
+            word-break: break-all;
+        }
+        
+        .console-text, .console-stack {
+            font-family: monospace;
+            white-space: pre-wrap;
+            word-break: break-all;
+        }
+        
+        .evidence {
+            margin-top: 10px;
+            padding: 10px;
+            background-color: #f8f9fa;
+            border-radius: var(--border-radius);
+            word-break: break-all;
+        }
+        
+        .evidence a {
+            margin-right: 15px;
+        }
+        
+        .panic-stack {
+            background-color: #f8f9fa;
+            padding: 10px;
+            border-radius: var(--border-radius);
+            font-size: 0.85em;
+            white-space: pre-wrap;
+            word-break: break-all;
+        }
+        
         .collapsible {
             cursor: pointer;
         }
@@ -566,8 +1601,86 @@ const reportTemplate = `
             
             // 计算通过率并更新进度条
             updateProgressBar();
+            
+            // 初始化可排序表格
+            initSortableTables();
+            
+            // 初始化截图时间线
+            initFilmstrips();
+            
+            // 初始化标签筛选
+            initTagFilter();
         });
         
+        // 初始化标签筛选，只显示带有所选标签的测试
+        function initTagFilter() {
+            const buttons = document.querySelectorAll('.tag-filter-button');
+            buttons.forEach(function(button) {
+                button.addEventListener('click', function() {
+                    buttons.forEach(function(b) { b.classList.remove('active'); });
+                    button.classList.add('active');
+                    const tag = button.dataset.tag;
+                    document.querySelectorAll('.test-result').forEach(function(test) {
+                        const tags = test.dataset.tags ? test.dataset.tags.split(' ') : [];
+                        test.style.display = !tag || tags.includes(tag) ? '' : 'none';
+                    });
+                });
+            });
+        }
+        
+        // 初始化截图时间线，拖动滑块或点击缩略图切换截图
+        function initFilmstrips() {
+            document.querySelectorAll('.filmstrip').forEach(function(filmstrip) {
+                const current = filmstrip.querySelector('.filmstrip-current');
+                const caption = filmstrip.querySelector('.filmstrip-caption');
+                const slider = filmstrip.querySelector('.filmstrip-slider');
+                const frames = filmstrip.querySelectorAll('.filmstrip-frame');
+                
+                function show(index) {
+                    const frame = frames[index];
+                    current.src = frame.dataset.src;
+                    caption.textContent = (index + 1) + '/' + frames.length + ' ' + frame.dataset.caption;
+                    slider.value = index;
+                    frames.forEach(function(f) { f.classList.remove('active'); });
+                    frame.classList.add('active');
+                }
+                
+                slider.max = frames.length - 1;
+                slider.addEventListener('input', function() { show(parseInt(slider.value, 10)); });
+                frames.forEach(function(frame, index) {
+                    frame.addEventListener('click', function() { show(index); });
+                });
+                show(0);
+            });
+        }
+        
+        // 初始化可排序表格，点击表头按该列排序
+        function initSortableTables() {
+            document.querySelectorAll('table.sortable').forEach(function(table) {
+                const headers = table.querySelectorAll('thead th');
+                headers.forEach(function(th, index) {
+                    th.addEventListener('click', function() {
+                        const asc = !th.classList.contains('sort-asc');
+                        headers.forEach(function(h) { h.classList.remove('sort-asc', 'sort-desc'); });
+                        th.classList.add(asc ? 'sort-asc' : 'sort-desc');
+                        
+                        const numeric = th.dataset.type === 'number';
+                        const tbody = table.querySelector('tbody');
+                        const rows = Array.from(tbody.querySelectorAll('tr'));
+                        rows.sort(function(a, b) {
+                            const cellA = a.children[index];
+                            const cellB = b.children[index];
+                            const valueA = cellA.dataset.value !== undefined ? cellA.dataset.value : cellA.textContent;
+                            const valueB = cellB.dataset.value !== undefined ? cellB.dataset.value : cellB.textContent;
+                            const result = numeric ? parseFloat(valueA) - parseFloat(valueB) : valueA.localeCompare(valueB);
+                            return asc ? result : -result;
+                        });
+                        rows.forEach(function(row) { tbody.appendChild(row); });
+                    });
+                });
+            });
+        }
+        
         // 初始化可折叠元素
         function initCollapsible() {
             const collapsibles = document.querySelectorAll('.collapsible');
@@ -632,6 +1745,9 @@ const reportTemplate = `
         <header>
             <h1>测试报告: {{.Title}}</h1>
             <p class="timestamp">生成时间: {{.StartTime.Format "2006-01-02 15:04:05"}}</p>
+            {{if .TrendsLink}}
+            <p><a href="{{.TrendsLink}}" target="_blank">查看历史趋势</a></p>
+            {{end}}
         </header>
         
         <section class="summary">
@@ -649,6 +1765,26 @@ const reportTemplate = `
                 </div>
             </div>
             
+            {{if .Launches}}
+            <h3>浏览器启动</h3>
+            <table class="data-table">
+                <tr><th>浏览器</th><th>启动时间</th><th>原因</th><th>耗时</th></tr>
+                {{range .Launches}}
+                <tr>
+                    <td>{{.Browser}}</td>
+                    <td>{{.Time.Format "15:04:05"}}</td>
+                    <td>{{.Reason}}</td>
+                    <td><span class="duration">{{.Duration}}</span></td>
+                </tr>
+                {{end}}
+            </table>
+            {{end}}
+            
+            {{if .Hooks}}
+            <h3>钩子</h3>
+            {{template "hooks" .Hooks}}
+            {{end}}
+            
             <h3>测试统计</h3>
             <div class="stats">
                 <div class="stat-box total">
@@ -663,6 +1799,12 @@ const reportTemplate = `
                     <h3>失败</h3>
                     <p>{{.FailedSteps}}</p>
                 </div>
+                {{if .TimedOutSteps}}
+                <div class="stat-box timed-out">
+                    <h3>超时</h3>
+                    <p>{{.TimedOutSteps}}</p>
+                </div>
+                {{end}}
             </div>
             
             <h3>通过率</h3>
@@ -674,10 +1816,29 @@ const reportTemplate = `
         <section class="test-results">
             <h2>测试详情</h2>
             
+            {{if .TagFilter}}
+            <p><strong>标签筛选:</strong> <code>{{.TagFilter}}</code></p>
+            {{end}}
+            
+            {{if .Tags}}
+            <div class="tag-filter">
+                <button class="tag-filter-button active" data-tag="">全部</button>
+                {{range .Tags}}
+                <button class="tag-filter-button" data-tag="{{.}}">{{.}}</button>
+                {{end}}
+            </div>
+            {{end}}
+            
             {{range .Tests}}
-            <div class="test-result {{.Status | lower}}">
+            <div class="test-result {{.Status | lower}}" data-tags="{{join .Tags " "}}">
                 <div class="test-header">
-                    <div class="test-title">{{.Name}}</div>
+                    <div class="test-title">
+                        {{.Name}}
+                        {{if $.Merged}}<span class="browser-badge">{{.Browser}}</span>{{end}}
+                        {{range .Tags}}<span class="tag-badge">{{.}}</span>{{end}}
+                        {{if .Flakiness.Flaky}}<span class="flaky-badge" title="最近 {{.Flakiness.Runs}} 次运行中通过 {{.Flakiness.Passed}} 次，结果翻转 {{.Flakiness.Flips}} 次">不稳定 {{.Flakiness.Percent}}</span>{{end}}
+                        {{if .Quarantined}}<span class="quarantine-badge" title="失败不影响退出码">已隔离</span>{{end}}
+                    </div>
                     <div class="test-status status-{{.Status | lower}}">{{.Status}}</div>
                 </div>
                 
@@ -693,34 +1854,118 @@ const reportTemplate = `
                     <div class="test-info-item">
                         <p><strong>结果:</strong> {{.Message}}</p>
                     </div>
+                    {{if .HARPath}}
+                    <div class="test-info-item">
+                        <p><strong>HAR ({{.HARMode}}):</strong> <a href="{{.HARPath}}" target="_blank">{{.HARPath}}</a></p>
+                    </div>
+                    {{end}}
                 </div>
                 
+                {{with .Panic}}
+                <div class="test-panic">
+                    <h3 class="collapsible">Panic</h3>
+                    <div class="content">
+                        <div class="error">panic: {{.Value}}</div>
+                        <pre class="panic-stack">{{.Stack}}</pre>
+                        {{with .Evidence}}{{template "evidence" .}}{{end}}
+                        {{if .Screenshot}}
+                        <div class="screenshot-container">
+                            <img class="screenshot" src="{{.Screenshot}}" alt="发生panic时的页面截图">
+                        </div>
+                        {{end}}
+                    </div>
+                </div>
+                {{end}}
+                
+                {{if .Hooks}}
+                <div class="test-hooks">
+                    <h3 class="collapsible">钩子 ({{len .Hooks}})</h3>
+                    <div class="content">
+                        {{template "hooks" .Hooks}}
+                    </div>
+                </div>
+                {{end}}
+                
                 {{if .Steps}}
                 <div class="test-steps">
                     <h3 class="collapsible">测试步骤 ({{len .Steps}})</h3>
                     <div class="content">
-                        {{range .Steps}}
-                        <div class="step {{.Status | lower}}">
-                            <div class="step-header">
-                                <div class="step-name">{{.Name}}</div>
-                                <div class="step-status status-{{.Status | lower}}">{{.Status}}</div>
-                            </div>
-                            
-                            {{if .Message}}
-                            <div class="step-details">{{.Message}}</div>
+                        {{template "steps" .Steps}}
+                    </div>
+                </div>
+                {{end}}
+                
+                {{if .Filmstrip}}
+                <div class="test-filmstrip">
+                    <h3 class="collapsible">截图时间线 ({{len .Filmstrip}})</h3>
+                    <div class="content filmstrip">
+                        <div class="filmstrip-viewer">
+                            <img class="screenshot filmstrip-current" src="{{(index .Filmstrip 0).Path}}" alt="步骤截图">
+                            <p class="filmstrip-caption"></p>
+                        </div>
+                        <input type="range" class="filmstrip-slider" min="0" max="0" value="0">
+                        <div class="filmstrip-frames">
+                            {{range $index, $frame := .Filmstrip}}
+                            <figure class="filmstrip-frame" data-index="{{$index}}" data-src="{{$frame.Path}}" data-caption="{{$frame.Step}} · {{$frame.Label}} · {{$frame.Time.Format "15:04:05.000"}}">
+                                <img src="{{$frame.Path}}" alt="{{$frame.Step}} {{$frame.Label}}">
+                                <figcaption>{{$frame.Step}}<br>{{$frame.Label}}</figcaption>
+                            </figure>
                             {{end}}
-                            
-                            {{if .Error}}
-                            <div class="error">{{.Error}}</div>
+                        </div>
+                    </div>
+                </div>
+                {{end}}
+                
+                {{template "console" .Console}}
+                
+                {{if .Network}}
+                <div class="test-network">
+                    <h3 class="collapsible collapsed">网络请求 ({{len .Network}})</h3>
+                    <div class="content">
+                        <table class="data-table sortable">
+                            <thead>
+                                <tr><th data-type="text">方法</th><th data-type="text">URL</th><th data-type="text">类型</th><th data-type="number">状态码</th><th data-type="number">耗时(ms)</th><th data-type="number">大小(字节)</th></tr>
+                            </thead>
+                            <tbody>
+                                {{range .Network}}
+                                <tr{{if .Failed}} class="request-failed"{{end}}>
+                                    <td>{{.Method}}</td>
+                                    <td>{{.URL}}</td>
+                                    <td>{{.ResourceType}}</td>
+                                    <td data-value="{{.Status}}">{{if .Failure}}{{.Failure}}{{else}}{{.Status}}{{end}}</td>
+                                    <td data-value="{{.DurationMs}}">{{.DurationMs}}</td>
+                                    <td data-value="{{.Size}}">{{if ge .Size 0}}{{.Size}}{{else}}-{{end}}</td>
+                                </tr>
+                                {{end}}
+                            </tbody>
+                        </table>
+                    </div>
+                </div>
+                {{end}}
+                
+                {{if .Mocks}}
+                <div class="test-mocks">
+                    <h3 class="collapsible">接口模拟 ({{len .Mocks}})</h3>
+                    <div class="content">
+                        <table class="data-table">
+                            <tr><th>匹配模式</th><th>方法</th><th>状态码</th><th>响应来源</th><th>命中次数</th></tr>
+                            {{range .Mocks}}
+                            <tr{{if eq .Hits 0}} class="unused"{{end}}>
+                                <td>{{.Pattern}}</td>
+                                <td>{{if .Method}}{{.Method}}{{else}}*{{end}}</td>
+                                <td>{{.Status}}</td>
+                                <td>{{.Source}}</td>
+                                <td>{{.Hits}}</td>
+                            </tr>
                             {{end}}
-                            
-                            {{if .Screenshot}}
-                            <div class="screenshot-container">
-                                <p><a href="{{.Screenshot}}" target="_blank">在新窗口中查看截图</a></p>
-                                <img class="screenshot" src="{{.Screenshot}}" alt="测试截图">
-                            </div>
+                        </table>
+                        {{if .Unmatched}}
+                        <p><strong>未匹配的请求 ({{len .Unmatched}}):</strong></p>
+                        <ul>
+                            {{range .Unmatched}}
+                            <li>{{.}}</li>
                             {{end}}
-                        </div>
+                        </ul>
                         {{end}}
                     </div>
                 </div>
@@ -731,4 +1976,158 @@ const reportTemplate = `
     </div>
 </body>
 </html>
-`
\ No newline at end of file
+{{define "steps"}}
+{{range .}}
+<div class="step {{.Status | lower}}">
+    <div class="step-header">
+        <div class="step-name">{{.Name}}</div>
+        <div>
+            <span class="duration">{{.Duration}}</span>
+            <span class="step-status status-{{.Status | lower}}">{{.Status}}</span>
+        </div>
+    </div>
+    
+    {{if .Message}}
+    <div class="step-details">{{.Message}}</div>
+    {{end}}
+    
+    {{if .Error}}
+    <div class="error">{{.Error}}</div>
+    {{end}}
+    
+    {{template "console" .Console}}
+    
+    {{range .Visual}}
+    <div class="visual-result {{if .Passed}}success{{else}}failure{{end}}">
+        <p><strong>视觉对比 {{.Name}}:</strong> {{.Message}}</p>
+        <div class="visual-images">
+            {{if .Expected}}
+            <figure>
+                <img class="screenshot" src="{{.Expected}}" alt="基线截图">
+                <figcaption>基线</figcaption>
+            </figure>
+            {{end}}
+            <figure>
+                <img class="screenshot" src="{{.Actual}}" alt="本次截图">
+                <figcaption>本次</figcaption>
+            </figure>
+            {{if .Diff}}
+            <figure>
+                <img class="screenshot" src="{{.Diff}}" alt="差异图">
+                <figcaption>差异</figcaption>
+            </figure>
+            {{end}}
+        </div>
+    </div>
+    {{end}}
+    
+    {{range .Performance}}
+    <div class="perf-result">
+        <p><strong>性能指标:</strong> {{.URL}}</p>
+        <div class="perf-chart">
+            {{range .Items}}
+            <div class="perf-row{{if .OverBudget}} over-budget{{end}}">
+                <div class="perf-name">{{.Name}}</div>
+                <div class="perf-track">
+                    <div class="perf-bar" style="width: {{printf "%.1f" .Percent}}%"></div>
+                </div>
+                <div class="perf-value">{{if gt .Value 0.0}}{{printf "%.0f" .Value}}ms{{else}}-{{end}}{{if gt .Budget 0.0}} / {{printf "%.0f" .Budget}}ms{{end}}</div>
+            </div>
+            {{end}}
+        </div>
+        {{range .Violations}}
+        <div class="error">{{.}}</div>
+        {{end}}
+    </div>
+    {{end}}
+    
+    {{range .Accessibility}}
+    <div class="a11y-result {{if .Passed}}success{{else}}failure{{end}}">
+        <p><strong>无障碍检查 {{.Name}}:</strong> {{.Message}}（通过 {{.Passes}} 条规则，{{.Incomplete}} 条需人工确认）</p>
+        {{if .Violations}}
+        <table class="data-table sortable">
+            <thead>
+                <tr><th data-type="number">严重程度</th><th data-type="text">规则</th><th data-type="text">说明</th><th data-type="text">问题元素</th></tr>
+            </thead>
+            <tbody>
+                {{range .Violations}}
+                <tr>
+                    <td data-value="{{.Rank}}"><span class="impact-badge impact-{{.Impact}}">{{.Severity}}</span></td>
+                    <td><a href="{{.HelpURL}}" target="_blank">{{.ID}}</a></td>
+                    <td>{{.Help}}</td>
+                    <td>{{range .Nodes}}<div class="a11y-node" title="{{.FailureSummary}}"><code>{{.Selector}}</code></div>{{end}}</td>
+                </tr>
+                {{end}}
+            </tbody>
+        </table>
+        {{end}}
+        {{if .Screenshot}}
+        <div class="screenshot-container">
+            <img class="screenshot" src="{{.Screenshot}}" alt="标注问题元素的截图">
+        </div>
+        {{end}}
+    </div>
+    {{end}}
+    
+    {{if .Screenshot}}
+    <div class="screenshot-container">
+        <p><a href="{{.Screenshot}}" target="_blank">在新窗口中查看截图</a></p>
+        <img class="screenshot" src="{{.Screenshot}}" alt="测试截图">
+    </div>
+    {{end}}
+    
+    {{with .Evidence}}{{template "evidence" .}}{{end}}
+    
+    {{if .Steps}}
+    <div class="sub-steps">
+        {{template "steps" .Steps}}
+    </div>
+    {{end}}
+</div>
+{{end}}
+{{end}}
+{{define "evidence"}}
+<div class="evidence">
+    <p><strong>页面:</strong> {{if .Title}}{{.Title}} · {{end}}<a href="{{.URL}}" target="_blank">{{.URL}}</a></p>
+    {{if or .HTML .AriaSnapshot}}
+    <p>
+        {{if .HTML}}<a href="{{.HTML}}" target="_blank">查看页面HTML</a>{{end}}
+        {{if .AriaSnapshot}}<a href="{{.AriaSnapshot}}" target="_blank">查看无障碍树快照</a>{{end}}
+    </p>
+    {{end}}
+</div>
+{{end}}
+{{define "hooks"}}
+<table class="data-table">
+    <tr><th>浏览器</th><th>阶段</th><th>钩子</th><th>状态</th><th>耗时</th><th>信息</th></tr>
+    {{range .}}
+    <tr>
+        <td>{{if .Browser}}{{.Browser}}{{else}}全部浏览器{{end}}</td>
+        <td>{{.Stage}}</td>
+        <td>{{.Name}}</td>
+        <td><span class="hook-status status-{{.Status | lower}}">{{.Status}}</span></td>
+        <td><span class="duration">{{.Duration}}</span></td>
+        <td>{{.Message}}</td>
+    </tr>
+    {{end}}
+</table>
+{{end}}
+{{define "console"}}
+{{if .}}
+<div class="console-log">
+    <h4 class="collapsible collapsed">控制台消息 ({{len .}})</h4>
+    <div class="content">
+        {{range .}}
+        <div class="console-entry console-{{.Level}}">
+            <span class="console-level">{{.Level}}</span>
+            <span class="timestamp">{{.Time.Format "15:04:05.000"}}</span>
+            {{if .Location}}<span class="console-location">{{.Location}}</span>{{end}}
+            <div class="console-text">{{.Text}}</div>
+            {{if .Stack}}<pre class="console-stack">{{.Stack}}</pre>{{end}}
+        </div>
+        {{end}}
+    </div>
+</div>
+{{end}}
+{{end}}
+`
@@ -0,0 +1,405 @@
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultResultsDir 默认的JSON结果目录
+const DefaultResultsDir = "./results"
+
+// ResultFile 一个浏览器（或分片）测试结果的JSON文件内容，用于合并报告
+type ResultFile struct {
+	Title      string          `json:"title"`
+	Browser    string          `json:"browser"`
+	Shard      string          `json:"shard,omitempty"`
+	TagFilter  string          `json:"tag_filter,omitempty"`
+	StartTime  time.Time       `json:"start_time"`
+	Tests      []Test          `json:"tests"`
+	Launches   []BrowserLaunch `json:"launches"`
+	Hooks      []HookResult    `json:"hooks,omitempty"`
+	SuiteHooks []HookResult    `json:"suite_hooks,omitempty"`
+}
+
+// MarshalJSON 将步骤的错误序列化为字符串
+func (s TestStep) MarshalJSON() ([]byte, error) {
+	type step TestStep
+	errorText := ""
+	if s.Error != nil {
+		errorText = s.Error.Error()
+	}
+	return json.Marshal(struct {
+		step
+		Error string `json:"Error,omitempty"`
+	}{step(s), errorText})
+}
+
+// UnmarshalJSON 从字符串还原步骤的错误
+func (s *TestStep) UnmarshalJSON(data []byte) error {
+	type step TestStep
+	aux := struct {
+		*step
+		Error string `json:"Error"`
+	}{step: (*step)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Error != "" {
+		s.Error = errors.New(aux.Error)
+	}
+	return nil
+}
+
+// Result 返回报告中的测试结果，shard为分片说明，未分片时为空
+func (r *ReportManager) Result(shard string) ResultFile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return ResultFile{
+		Title:      r.Title,
+		Browser:    r.browser,
+		Shard:      shard,
+		TagFilter:  r.tagFilter,
+		StartTime:  r.StartTime,
+		Tests:      append([]Test(nil), r.Tests...),
+		Launches:   append([]BrowserLaunch(nil), r.Launches...),
+		Hooks:      append([]HookResult(nil), r.Hooks...),
+		SuiteHooks: append([]HookResult(nil), r.SuiteHooks...),
+	}
+}
+
+// WriteResults 将报告中的测试结果写入JSON文件，shard为分片说明，未分片时为空
+func (r *ReportManager) WriteResults(path, shard string) error {
+	data, err := json.MarshalIndent(r.Result(shard), "", "  ")
+	if err != nil {
+		return fmt.Errorf("无法序列化测试结果: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建结果目录: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("无法写入测试结果: %w", err)
+	}
+	return nil
+}
+
+// ResultsPath 返回浏览器（或分片）测试结果文件的路径
+func ResultsPath(dir, browser, shard string) string {
+	if dir == "" {
+		dir = DefaultResultsDir
+	}
+	name := sanitizeFileName(browser)
+	if shard != "" {
+		name += "-shard-" + strings.ReplaceAll(shard, "/", "-of-")
+	}
+	return filepath.Join(dir, name+".json")
+}
+
+// ClearResults 删除之前运行留下的JSON结果，避免合并报告时混入过期的结果
+// 不分片时清空结果目录；分片执行时同一目录中可能有其他分片的结果，只删除本分片各浏览器的结果文件
+func ClearResults(dir string, browsers []string, shard Shard) error {
+	if dir == "" {
+		dir = DefaultResultsDir
+	}
+	var matches []string
+	if shard.Enabled() {
+		for _, browser := range browsers {
+			path := ResultsPath(dir, browser, shard.String())
+			if _, err := os.Stat(path); err == nil {
+				matches = append(matches, path)
+			}
+		}
+	} else {
+		var err error
+		matches, err = filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return fmt.Errorf("无法列出结果目录 %s: %w", dir, err)
+		}
+	}
+	for _, match := range matches {
+		if err := os.Remove(match); err != nil {
+			return fmt.Errorf("无法删除结果文件 %s: %w", match, err)
+		}
+	}
+	return nil
+}
+
+// CheckShards 检查要合并的结果来自同一次完整的分片执行：分片总数必须一致，
+// 同一浏览器的同一分片不能重复，每个浏览器的分片 1..n 都必须存在
+func CheckShards(results []ResultFile) error {
+	total := 0
+	seen := map[string]bool{}
+	var browsers []string
+	for _, result := range results {
+		shard, err := ParseShard(result.Shard)
+		if err != nil {
+			return fmt.Errorf("%s 浏览器的结果: %w", result.Browser, err)
+		}
+		if total == 0 {
+			total = shard.Total
+		} else if shard.Total != total {
+			return fmt.Errorf("结果文件的分片总数不一致（%d 和 %d），可能混入了之前运行的结果", total, shard.Total)
+		}
+		key := result.Browser + " " + shard.String()
+		if seen[key] && shard.Enabled() {
+			return fmt.Errorf("%s 浏览器的分片 %s 有多个结果文件", result.Browser, shard)
+		}
+		if seen[key] {
+			return fmt.Errorf("%s 浏览器有多个未分片的结果文件", result.Browser)
+		}
+		seen[key] = true
+		if !containsString(browsers, result.Browser) {
+			browsers = append(browsers, result.Browser)
+		}
+	}
+
+	if total <= 1 {
+		return nil
+	}
+	for _, browser := range browsers {
+		var missing []string
+		for index := 1; index <= total; index++ {
+			shard := Shard{Index: index, Total: total}
+			if !seen[browser+" "+shard.String()] {
+				missing = append(missing, shard.String())
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%s 浏览器缺少分片 %s 的结果文件", browser, strings.Join(missing, "、"))
+		}
+	}
+	return nil
+}
+
+// LoadResults 读取JSON结果文件，参数可以是文件或目录（读取目录下所有 .json 文件）
+func LoadResults(paths ...string) ([]ResultFile, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取结果路径 %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("无法列出结果目录 %s: %w", path, err)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+
+	var results []ResultFile
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("无法读取结果文件 %s: %w", file, err)
+		}
+		var result ResultFile
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("无法解析结果文件 %s: %w", file, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// MergeResults 将多个结果文件合并为一个报告，测试按浏览器和开始时间排序
+// 同一次运行的各浏览器结果中都有相同的整次运行级别钩子，只保留一份
+func MergeResults(title string, results []ResultFile) *ReportManager {
+	report := NewReportManager(title)
+	report.merged = true
+
+	var filters []string
+	seenSuiteHooks := make(map[string]bool)
+	for i, result := range results {
+		if i == 0 || result.StartTime.Before(report.StartTime) {
+			report.StartTime = result.StartTime
+		}
+		for _, test := range result.Tests {
+			if test.Browser == "" {
+				test.Browser = result.Browser
+			}
+			report.Tests = append(report.Tests, test)
+		}
+		report.Launches = append(report.Launches, result.Launches...)
+		report.Hooks = append(report.Hooks, result.Hooks...)
+		for _, hook := range result.SuiteHooks {
+			key := fmt.Sprintf("%s|%s|%d", hook.Stage, hook.Name, hook.Time.UnixNano())
+			if !seenSuiteHooks[key] {
+				seenSuiteHooks[key] = true
+				report.SuiteHooks = append(report.SuiteHooks, hook)
+			}
+		}
+		if result.TagFilter != "" && !containsString(filters, result.TagFilter) {
+			filters = append(filters, result.TagFilter)
+		}
+	}
+	report.tagFilter = strings.Join(filters, "；")
+
+	sort.SliceStable(report.Tests, func(i, j int) bool {
+		if report.Tests[i].Browser != report.Tests[j].Browser {
+			return report.Tests[i].Browser < report.Tests[j].Browser
+		}
+		return report.Tests[i].StartTime.Before(report.Tests[j].StartTime)
+	})
+	return report
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// junitTestSuites JUnit XML的根节点
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite 一个浏览器的测试
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase 一个测试
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitFailure 测试失败信息
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitSkipped 隔离的失败测试以跳过上报，避免影响CI结果
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// GenerateJUnit 生成JUnit XML报告，每个浏览器一个testsuite
+func (r *ReportManager) GenerateJUnit(path string) error {
+	r.mu.Lock()
+	tests := append([]Test(nil), r.Tests...)
+	title := r.Title
+	r.mu.Unlock()
+
+	root := junitTestSuites{Name: title}
+	suiteIndex := map[string]int{}
+	var total time.Duration
+	for _, test := range tests {
+		browser := test.Browser
+		index, ok := suiteIndex[browser]
+		if !ok {
+			index = len(root.Suites)
+			suiteIndex[browser] = index
+			root.Suites = append(root.Suites, junitTestSuite{
+				Name:      browser,
+				Timestamp: test.StartTime.Format("2006-01-02T15:04:05"),
+			})
+		}
+		suite := &root.Suites[index]
+
+		testCase := junitTestCase{
+			Name:      test.Name,
+			ClassName: browser,
+			Time:      junitSeconds(test.Duration),
+			SystemOut: junitSteps(test),
+		}
+		if test.Status != "Success" {
+			message := test.Message
+			if test.Quarantined {
+				testCase.Skipped = &junitSkipped{Message: "已隔离的不稳定测试: " + message}
+				suite.Skipped++
+			} else {
+				testCase.Failure = &junitFailure{Message: message, Text: testCase.SystemOut}
+				testCase.SystemOut = ""
+				suite.Failures++
+				root.Failures++
+			}
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+		root.Tests++
+		total += test.Duration
+	}
+	for i := range root.Suites {
+		var duration time.Duration
+		for _, test := range tests {
+			if test.Browser == root.Suites[i].Name {
+				duration += test.Duration
+			}
+		}
+		root.Suites[i].Time = junitSeconds(duration)
+	}
+	root.Time = junitSeconds(total)
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return fmt.Errorf("无法生成JUnit报告: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("无法创建JUnit报告目录: %w", err)
+	}
+	if err := os.WriteFile(path, append([]byte(xml.Header), data...), 0644); err != nil {
+		return fmt.Errorf("无法写入JUnit报告: %w", err)
+	}
+	return nil
+}
+
+// junitSeconds 将耗时格式化为JUnit使用的秒数
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// junitSteps 将测试步骤整理为文本
+func junitSteps(test Test) string {
+	var lines []string
+	walkSteps(test.Steps, 0, func(step TestStep, depth int) {
+		line := fmt.Sprintf("%s[%s] %s (%s)", strings.Repeat("  ", depth), step.Status, step.Name, step.Duration)
+		if step.Message != "" {
+			line += ": " + step.Message
+		}
+		if step.Error != nil {
+			line += fmt.Sprintf(" (%v)", step.Error)
+		}
+		if step.Evidence != nil {
+			line += " @ " + step.Evidence.URL
+		}
+		lines = append(lines, line)
+	})
+	if test.Panic != nil {
+		lines = append(lines, "panic: "+test.Panic.Value, test.Panic.Stack)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckShards(t *testing.T) {
+	chromium := func(shard string) ResultFile { return ResultFile{Browser: "chromium", Shard: shard} }
+	firefox := func(shard string) ResultFile { return ResultFile{Browser: "firefox", Shard: shard} }
+
+	valid := [][]ResultFile{
+		{chromium(""), firefox("")},
+		{chromium("1/2"), chromium("2/2"), firefox("2/2"), firefox("1/2")},
+	}
+	for _, results := range valid {
+		if err := CheckShards(results); err != nil {
+			t.Errorf("CheckShards(%v) = %v", results, err)
+		}
+	}
+
+	invalid := []struct {
+		results []ResultFile
+		message string
+	}{
+		{[]ResultFile{chromium("1/2"), chromium("2/3")}, "分片总数不一致（2 和 3）"},
+		{[]ResultFile{chromium("1/2"), chromium("")}, "分片总数不一致（2 和 1）"},
+		{[]ResultFile{chromium("1/2"), chromium("1/2")}, "chromium 浏览器的分片 1/2 有多个结果文件"},
+		{[]ResultFile{firefox(""), firefox("")}, "firefox 浏览器有多个未分片的结果文件"},
+		{[]ResultFile{chromium("3/2")}, "chromium 浏览器的结果"},
+		{[]ResultFile{chromium("1/3"), chromium("3/3"), firefox("1/3"), firefox("2/3"), firefox("3/3")}, "chromium 浏览器缺少分片 2/3 的结果文件"},
+		{[]ResultFile{chromium("1/3"), firefox("2/3"), firefox("1/3"), firefox("3/3")}, "chromium 浏览器缺少分片 2/3、3/3 的结果文件"},
+	}
+	for _, c := range invalid {
+		err := CheckShards(c.results)
+		if err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("CheckShards(%v) = %v，应包含 %q", c.results, err, c.message)
+		}
+	}
+}
+
+func TestClearResults(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		ResultsPath(dir, "chromium", "1/2"),
+		ResultsPath(dir, "chromium", "2/2"),
+		ResultsPath(dir, "firefox", "1/2"),
+		ResultsPath(dir, "firefox", ""),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 分片执行只删除本分片的结果，其他分片的结果保留下来用于合并
+	if err := ClearResults(dir, []string{"chromium", "firefox"}, Shard{Index: 1, Total: 2}); err != nil {
+		t.Fatal(err)
+	}
+	remaining, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	want := []string{ResultsPath(dir, "chromium", "2/2"), ResultsPath(dir, "firefox", "")}
+	if !reflect.DeepEqual(remaining, want) {
+		t.Errorf("分片执行后剩余 %v，期望 %v", remaining, want)
+	}
+
+	// 不分片时清空结果目录
+	if err := ClearResults(dir, []string{"chromium"}, Shard{Index: 1, Total: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(remaining) != 0 {
+		t.Errorf("不分片时应删除所有结果，剩余 %v", remaining)
+	}
+}
+
+func TestMergeResultsSuiteHooks(t *testing.T) {
+	start := time.Now()
+	beforeAll := HookResult{Stage: HookBeforeAll, Name: "准备数据", Status: "Failure", Time: start}
+	afterAll := HookResult{Stage: HookAfterAll, Name: "清理数据", Status: "Success", Time: start.Add(time.Minute)}
+	chromium := ResultFile{Browser: "chromium", SuiteHooks: []HookResult{beforeAll, afterAll}}
+	firefox := ResultFile{
+		Browser:    "firefox",
+		SuiteHooks: []HookResult{beforeAll, afterAll},
+		Hooks:      []HookResult{{Browser: "firefox", Stage: HookBeforeBrowser, Name: "检查页面", Status: "Failure", Time: start}},
+	}
+
+	// 同一次运行的整次运行级别钩子只保留一份，浏览器级别的钩子照常合并
+	report := MergeResults("合并报告", []ResultFile{chromium, firefox})
+	if len(report.SuiteHooks) != 2 {
+		t.Errorf("SuiteHooks = %v，应只有 2 个", report.SuiteHooks)
+	}
+	if len(report.Hooks) != 1 {
+		t.Errorf("Hooks = %v，应只有 1 个", report.Hooks)
+	}
+	if failures := report.BlockingFailures(); failures != 2 {
+		t.Errorf("BlockingFailures() = %d，应为 2", failures)
+	}
+
+	// 不同分片各自执行 BeforeAll，开始时间不同，都保留下来
+	shard := ResultFile{Browser: "chromium", Shard: "2/2", SuiteHooks: []HookResult{{Stage: HookBeforeAll, Name: "准备数据", Status: "Success", Time: start.Add(time.Second)}}}
+	report = MergeResults("合并报告", []ResultFile{chromium, firefox, shard})
+	if len(report.SuiteHooks) != 3 {
+		t.Errorf("SuiteHooks = %v，应有 3 个", report.SuiteHooks)
+	}
+}
@@ -2,19 +2,53 @@ package utils
 
 import (
 	"fmt"
+
 	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
 )
 
-// TakeScreenshot 捕获页面截图并保存到指定路径
-func TakeScreenshot(page playwright.Page, path string) error {
+// hideScrollbarsStyle 截图时注入的隐藏滚动条样式
+const hideScrollbarsStyle = `
+html, body { scrollbar-width: none !important; }
+::-webkit-scrollbar { display: none !important; }
+`
+
+// defaultScreenshotOptions 所有截图默认使用的稳定化选项
+var defaultScreenshotOptions config.ScreenshotConfig
+
+// SetDefaultScreenshotOptions 设置所有截图默认使用的稳定化选项
+func SetDefaultScreenshotOptions(options config.ScreenshotConfig) {
+	defaultScreenshotOptions = options
+}
+
+// TakeScreenshot 捕获页面截图并保存到指定路径，未传入options时使用默认稳定化选项
+func TakeScreenshot(page playwright.Page, path string, options ...config.ScreenshotConfig) error {
+	stabilize := resolveScreenshotOptions(options)
+	if err := prepareScreenshot(page, stabilize); err != nil {
+		return err
+	}
+
 	// 设置截图选项
-	options := playwright.PageScreenshotOptions{
+	screenshotOptions := playwright.PageScreenshotOptions{
 		Path:     playwright.String(path),
 		FullPage: playwright.Bool(true),
 	}
+	screenshotOptions.Mask = maskLocators(page, stabilize)
+	if stabilize.MaskColor != "" {
+		screenshotOptions.MaskColor = playwright.String(stabilize.MaskColor)
+	}
+	if stabilize.DisableAnimations {
+		screenshotOptions.Animations = playwright.ScreenshotAnimationsDisabled
+	}
+	if stabilize.HideCaret {
+		screenshotOptions.Caret = playwright.ScreenshotCaretHide
+	}
+	if stabilize.HideScrollbars {
+		screenshotOptions.Style = playwright.String(hideScrollbarsStyle)
+	}
 
 	// 捕获截图
-	_, err := page.Screenshot(options)
+	_, err := page.Screenshot(screenshotOptions)
 	if err != nil {
 		return fmt.Errorf("截图失败: %w", err)
 	}
@@ -22,8 +56,8 @@ func TakeScreenshot(page playwright.Page, path string) error {
 	return nil
 }
 
-// TakeElementScreenshot 捕获特定元素的截图并保存到指定路径
-func TakeElementScreenshot(page playwright.Page, selector string, path string) error {
+// TakeElementScreenshot 捕获特定元素的截图并保存到指定路径，未传入options时使用默认稳定化选项
+func TakeElementScreenshot(page playwright.Page, selector string, path string, options ...config.ScreenshotConfig) error {
 	// 查找元素
 	element, err := page.QuerySelector(selector)
 	if err != nil {
@@ -33,16 +67,62 @@ func TakeElementScreenshot(page playwright.Page, selector string, path string) e
 		return fmt.Errorf("元素 '%s' 不存在", selector)
 	}
 
+	stabilize := resolveScreenshotOptions(options)
+	if err := prepareScreenshot(page, stabilize); err != nil {
+		return err
+	}
+
 	// 设置截图选项
-	options := playwright.ElementHandleScreenshotOptions{
+	screenshotOptions := playwright.ElementHandleScreenshotOptions{
 		Path: playwright.String(path),
 	}
+	screenshotOptions.Mask = maskLocators(page, stabilize)
+	if stabilize.MaskColor != "" {
+		screenshotOptions.MaskColor = playwright.String(stabilize.MaskColor)
+	}
+	if stabilize.DisableAnimations {
+		screenshotOptions.Animations = playwright.ScreenshotAnimationsDisabled
+	}
+	if stabilize.HideCaret {
+		screenshotOptions.Caret = playwright.ScreenshotCaretHide
+	}
+	if stabilize.HideScrollbars {
+		screenshotOptions.Style = playwright.String(hideScrollbarsStyle)
+	}
 
 	// 捕获元素截图
-	_, err = element.Screenshot(options)
+	_, err = element.Screenshot(screenshotOptions)
 	if err != nil {
 		return fmt.Errorf("元素截图失败: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// resolveScreenshotOptions 返回本次截图使用的稳定化选项
+func resolveScreenshotOptions(options []config.ScreenshotConfig) config.ScreenshotConfig {
+	if len(options) > 0 {
+		return options[0]
+	}
+	return defaultScreenshotOptions
+}
+
+// prepareScreenshot 截图前的准备工作，如等待字体加载
+func prepareScreenshot(page playwright.Page, options config.ScreenshotConfig) error {
+	if !options.WaitForFonts {
+		return nil
+	}
+	if _, err := page.Evaluate("() => document.fonts.ready.then(() => true)"); err != nil {
+		return fmt.Errorf("等待字体加载失败: %w", err)
+	}
+	return nil
+}
+
+// maskLocators 将遮盖选择器转换为定位器
+func maskLocators(page playwright.Page, options config.ScreenshotConfig) []playwright.Locator {
+	var locators []playwright.Locator
+	for _, selector := range options.Mask {
+		locators = append(locators, page.Locator(selector))
+	}
+	return locators
+}
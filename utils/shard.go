@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Shard 表示 --shard i/n 指定的分片，Index 从1开始
+type Shard struct {
+	Index int
+	Total int
+}
+
+// ParseShard 解析 "i/n" 形式的分片参数，参数为空时返回不分片（1/1）
+func ParseShard(value string) (Shard, error) {
+	if strings.TrimSpace(value) == "" {
+		return Shard{Index: 1, Total: 1}, nil
+	}
+
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return Shard{}, fmt.Errorf("无效的分片参数 %q，格式应为 i/n", value)
+	}
+	index, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return Shard{}, fmt.Errorf("无效的分片序号 %q: %w", parts[0], err)
+	}
+	total, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return Shard{}, fmt.Errorf("无效的分片总数 %q: %w", parts[1], err)
+	}
+	if total < 1 || index < 1 || index > total {
+		return Shard{}, fmt.Errorf("无效的分片参数 %q，要求 1 <= i <= n", value)
+	}
+	return Shard{Index: index, Total: total}, nil
+}
+
+// Enabled 判断是否分片执行
+func (s Shard) Enabled() bool {
+	return s.Total > 1
+}
+
+// Includes 判断测试矩阵中第 position 个（从0开始）组合是否属于当前分片，按轮询方式分配
+func (s Shard) Includes(position int) bool {
+	if !s.Enabled() {
+		return true
+	}
+	return position%s.Total == s.Index-1
+}
+
+// String 返回 "i/n" 形式的分片说明，未分片时为空
+func (s Shard) String() string {
+	if !s.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
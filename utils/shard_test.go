@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	shard, err := ParseShard("")
+	if err != nil || shard != (Shard{Index: 1, Total: 1}) || shard.Enabled() {
+		t.Errorf(`ParseShard("") = %+v, %v，应为不分片`, shard, err)
+	}
+
+	shard, err = ParseShard(" 2 / 3 ")
+	if err != nil || shard != (Shard{Index: 2, Total: 3}) || !shard.Enabled() {
+		t.Errorf(`ParseShard(" 2 / 3 ") = %+v, %v`, shard, err)
+	}
+
+	// 1/1 与不分片相同
+	shard, err = ParseShard("1/1")
+	if err != nil || shard.Enabled() || shard.String() != "" {
+		t.Errorf(`ParseShard("1/1") = %+v, %v`, shard, err)
+	}
+
+	for _, value := range []string{"2", "1/2/3", "x/3", "1/y", "/3", "0/3", "4/3", "-1/3", "1/0"} {
+		if shard, err := ParseShard(value); err == nil {
+			t.Errorf("ParseShard(%q) = %+v，应返回错误", value, shard)
+		}
+	}
+}
+
+func TestShardIncludesRoundRobin(t *testing.T) {
+	// 7 个组合分到 3 个分片，每个组合只属于一个分片
+	got := map[string][]int{}
+	for index := 1; index <= 3; index++ {
+		shard := Shard{Index: index, Total: 3}
+		for position := 0; position < 7; position++ {
+			if shard.Includes(position) {
+				got[shard.String()] = append(got[shard.String()], position)
+			}
+		}
+	}
+	want := map[string][]int{
+		"1/3": {0, 3, 6},
+		"2/3": {1, 4},
+		"3/3": {2, 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("分配结果 %v，期望 %v", got, want)
+	}
+
+	unsharded := Shard{Index: 1, Total: 1}
+	for position := 0; position < 3; position++ {
+		if !unsharded.Includes(position) {
+			t.Errorf("不分片时应包括第 %d 个组合", position)
+		}
+	}
+}
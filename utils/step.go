@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// StepError 步骤失败的错误，Step 返回的错误都是这个类型，外层步骤据此说明是哪个子步骤失败
+type StepError struct {
+	Step string // 失败的步骤名称
+	Err  error
+}
+
+// Error 返回步骤失败的原因
+func (e *StepError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回步骤失败的原始错误
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Step 执行一个测试步骤并自动记录耗时：fn 返回nil时步骤成功；返回错误或panic时步骤失败，
+// 按截图模式保存失败截图。fn 中调用 Step 会创建子步骤，子步骤失败后外层步骤通常也应返回错误。
+// panic 记录后以 *PanicError 继续向上传递，由运行器恢复
+func (r *ReportManager) Step(name string, fn func() error) error {
+	return r.step(nil, name, fn)
+}
+
+// step 为 owner 测试执行一个步骤，owner 已不是当前测试时只执行 fn，不记录步骤
+func (r *ReportManager) step(owner *Test, name string, fn func() error) error {
+	step := r.startStep(owner, name)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			panicErr := NewPanicError(recovered)
+			if step != nil {
+				r.endStepFailure(step, "步骤发生panic", panicErr, "")
+			}
+			panic(panicErr)
+		}
+	}()
+
+	err := fn()
+	if step == nil {
+		return err
+	}
+	if err == nil {
+		r.endStepSuccess(step, "")
+		return nil
+	}
+
+	message := ""
+	var childErr *StepError
+	if errors.As(err, &childErr) {
+		message = fmt.Sprintf("子步骤「%s」失败", childErr.Step)
+	}
+	r.endStepFailure(step, message, err, "")
+	return &StepError{Step: name, Err: err}
+}
+
+// SetStepMessage 设置最内层正在执行的步骤的说明，步骤成功时显示在报告中
+func (r *ReportManager) SetStepMessage(message string) {
+	r.setStepMessage(nil, message)
+}
+
+// setStepMessage 设置 owner 测试最内层正在执行的步骤的说明
+func (r *ReportManager) setStepMessage(owner *Test, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if step := r.currentFor(owner); step != nil {
+		step.Message = message
+	}
+}
+
+// AttachScreenshot 为最内层正在执行的步骤附加截图（如视觉对比的差异图），步骤失败时代替自动截图
+func (r *ReportManager) AttachScreenshot(path string) {
+	r.attachScreenshot(nil, path)
+}
+
+// attachScreenshot 为 owner 测试最内层正在执行的步骤附加截图
+func (r *ReportManager) attachScreenshot(owner *Test, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if step := r.currentFor(owner); step != nil {
+		step.Screenshot = path
+	}
+}
+
+// TestReport 绑定到一个测试的步骤记录接口，供测试代码使用。测试超时结束后仍在执行的测试代码
+// 通过它写入的步骤、说明和截图都会被丢弃，不会记录到之后的测试中
+type TestReport struct {
+	report *ReportManager
+	test   *Test
+}
+
+// ForTest 返回绑定到当前测试的 TestReport，没有当前测试时返回的 TestReport 不记录任何内容
+func (r *ReportManager) ForTest() *TestReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	test := r.currentTest
+	if test == nil {
+		test = &Test{}
+	}
+	return &TestReport{report: r, test: test}
+}
+
+// Step 见 ReportManager.Step
+func (t *TestReport) Step(name string, fn func() error) error {
+	return t.report.step(t.test, name, fn)
+}
+
+// SetStepMessage 见 ReportManager.SetStepMessage
+func (t *TestReport) SetStepMessage(message string) {
+	t.report.setStepMessage(t.test, message)
+}
+
+// AttachScreenshot 见 ReportManager.AttachScreenshot
+func (t *TestReport) AttachScreenshot(path string) {
+	t.report.attachScreenshot(t.test, path)
+}
+
+// RecordVisual 见 ReportManager.RecordVisual
+func (t *TestReport) RecordVisual(result VisualResult) {
+	t.report.recordVisual(t.test, result)
+}
+
+// RecordAccessibility 见 ReportManager.RecordAccessibility
+func (t *TestReport) RecordAccessibility(result AccessibilityResult) {
+	t.report.recordAccessibility(t.test, result)
+}
+
+// walkSteps 按执行顺序遍历步骤及其子步骤，depth 为嵌套层级，顶层步骤为0
+func walkSteps(steps []TestStep, depth int, visit func(step TestStep, depth int)) {
+	for _, step := range steps {
+		visit(step, depth)
+		walkSteps(step.Steps, depth+1, visit)
+	}
+}
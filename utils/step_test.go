@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStepNesting(t *testing.T) {
+	report := NewReportManager("步骤")
+	report.StartTest("登录")
+	steps := report.ForTest()
+
+	err := steps.Step("登录", func() error {
+		if err := steps.Step("填写表单", func() error { return nil }); err != nil {
+			return err
+		}
+		return steps.Step("提交", func() error { return errors.New("按钮不可用") })
+	})
+
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != "登录" || err.Error() != "按钮不可用" {
+		t.Fatalf("Step() = %v", err)
+	}
+	outer := report.Tests[0].Steps[0]
+	if outer.Status != "Failure" || outer.Message != "子步骤「提交」失败" || len(outer.Steps) != 2 {
+		t.Errorf("外层步骤 %+v", outer)
+	}
+	if outer.Steps[0].Status != "Success" || outer.Steps[1].Status != "Failure" {
+		t.Errorf("子步骤状态 %s、%s", outer.Steps[0].Status, outer.Steps[1].Status)
+	}
+}
+
+func TestTestReportDropsWritesAfterTestEnds(t *testing.T) {
+	report := NewReportManager("步骤")
+	report.StartTest("超时的测试")
+	stale := report.ForTest()
+	report.LogTimeout(errors.New("测试超时"), 0)
+
+	// 下一个测试开始后，超时测试中仍在执行的代码继续调用步骤接口
+	report.StartTest("下一个测试")
+	current := report.ForTest()
+	if err := current.Step("当前步骤", func() error {
+		ran := false
+		stale.Step("过期的步骤", func() error {
+			ran = true
+			return nil
+		})
+		stale.SetStepMessage("过期的说明")
+		stale.AttachScreenshot("stale.png")
+		if !ran {
+			t.Error("过期的步骤仍应执行 fn")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	step := report.Tests[1].Steps[0]
+	if len(report.Tests[1].Steps) != 1 || len(step.Steps) != 0 {
+		t.Errorf("过期的步骤被记录到下一个测试: %+v", report.Tests[1].Steps)
+	}
+	if step.Message != "" || step.Screenshot != "" {
+		t.Errorf("过期的说明或截图被记录到下一个测试: %+v", step)
+	}
+}
+
+func TestLogPanicReusesStepEvidence(t *testing.T) {
+	report := NewReportManager("步骤")
+	report.StartTest("登录")
+	steps := report.ForTest()
+
+	var panicErr *PanicError
+	func() {
+		defer func() { panicErr = NewPanicError(recover()) }()
+		steps.Step("登录", func() error {
+			return steps.Step("提交", func() error { panic("空指针") })
+		})
+	}()
+
+	// 没有页面时步骤保存不到页面状态，这里模拟 endStepFailure 已为 panic 所在的步骤保存了截图和页面状态
+	inner := &report.Tests[0].Steps[0].Steps[0]
+	if inner.Status != "Failure" || !errors.Is(inner.Error, panicErr) {
+		t.Fatalf("panic所在的步骤 %+v", inner)
+	}
+	evidence := &FailureEvidence{URL: "https://example.com/login"}
+	inner.Screenshot = "01-提交-failure.png"
+	inner.Evidence = evidence
+
+	report.LogPanic(panicErr)
+	info := report.Tests[0].Panic
+	if info == nil || info.Evidence != evidence || info.Screenshot != "01-提交-failure.png" {
+		t.Errorf("Panic = %+v，应使用步骤已保存的截图和页面状态", info)
+	}
+}
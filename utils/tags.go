@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tagNode 标签表达式的语法树节点
+type tagNode func(tags map[string]bool) bool
+
+// TagFilter 标签筛选表达式，支持 &&、||、! 和括号，如 "smoke && !slow"
+type TagFilter struct {
+	expression string
+	root       tagNode
+	names      map[string]bool // 表达式中出现的标签名
+}
+
+// ParseTagFilter 解析标签筛选表达式，表达式为空时匹配所有测试
+func ParseTagFilter(expression string) (*TagFilter, error) {
+	filter := &TagFilter{expression: strings.TrimSpace(expression)}
+	if filter.expression == "" {
+		return filter, nil
+	}
+
+	parser := &tagParser{tokens: tokenizeTags(filter.expression), names: map[string]bool{}}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("无效的标签表达式 %q: %w", expression, err)
+	}
+	if parser.pos < len(parser.tokens) {
+		return nil, fmt.Errorf("无效的标签表达式 %q: 多余的 %q", expression, parser.tokens[parser.pos])
+	}
+	filter.root = root
+	filter.names = parser.names
+	return filter, nil
+}
+
+// Match 判断带有指定标签的测试是否满足表达式
+func (f *TagFilter) Match(tags []string) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	return f.root(set)
+}
+
+// Names 判断表达式中是否直接写出了 tags 中的某个标签，不论是否带 !
+func (f *TagFilter) Names(tags []string) bool {
+	if f == nil {
+		return false
+	}
+	for _, tag := range tags {
+		if f.names[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回原始表达式
+func (f *TagFilter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expression
+}
+
+// tokenizeTags 将表达式拆分为运算符、括号和标签名
+func tokenizeTags(expression string) []string {
+	var tokens []string
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '!':
+			tokens = append(tokens, string(r))
+			i++
+		case (r == '&' || r == '|') && i+1 < len(runes) && runes[i+1] == r:
+			tokens = append(tokens, string(runes[i:i+2]))
+			i += 2
+		default:
+			start := i
+			for i < len(runes) && isTagRune(runes[i]) {
+				i++
+			}
+			if i == start {
+				// 无法识别的字符单独作为一个记号，由解析器报错
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+	return tokens
+}
+
+// isTagRune 判断字符是否可以出现在标签名中
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == ':' || r == '.'
+}
+
+// tagParser 递归下降解析标签表达式，优先级从高到低为 !、&&、||
+type tagParser struct {
+	tokens []string
+	pos    int
+	names  map[string]bool // 已解析的标签名
+}
+
+// peek 返回下一个记号
+func (p *tagParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// parseOr 解析 a || b
+func (p *tagParser) parseOr() (tagNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(tags map[string]bool) bool { return l(tags) || r(tags) }
+	}
+	return left, nil
+}
+
+// parseAnd 解析 a && b
+func (p *tagParser) parseAnd() (tagNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(tags map[string]bool) bool { return l(tags) && r(tags) }
+	}
+	return left, nil
+}
+
+// parseUnary 解析 !a、(a) 和标签名
+func (p *tagParser) parseUnary() (tagNode, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("表达式不完整")
+	case token == "!":
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(tags map[string]bool) bool { return !operand(tags) }, nil
+	case token == "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		p.pos++
+		return inner, nil
+	case isTagName(token):
+		p.pos++
+		p.names[token] = true
+		return func(tags map[string]bool) bool { return tags[token] }, nil
+	default:
+		return nil, fmt.Errorf("意外的 %q", token)
+	}
+}
+
+// isTagName 判断记号是否为标签名
+func isTagName(token string) bool {
+	for _, r := range token {
+		if !isTagRune(r) {
+			return false
+		}
+	}
+	return token != ""
+}
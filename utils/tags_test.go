@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// mustParseTags 解析表达式，失败时终止测试
+func mustParseTags(t *testing.T, expression string) *TagFilter {
+	t.Helper()
+	filter, err := ParseTagFilter(expression)
+	if err != nil {
+		t.Fatalf("ParseTagFilter(%q): %v", expression, err)
+	}
+	return filter
+}
+
+func TestTagFilterEmptyMatchesAll(t *testing.T) {
+	for _, expression := range []string{"", "   "} {
+		filter := mustParseTags(t, expression)
+		if filter.String() != "" {
+			t.Errorf("ParseTagFilter(%q).String() = %q", expression, filter.String())
+		}
+		if !filter.Match(nil) || !filter.Match([]string{"slow"}) {
+			t.Errorf("空表达式 %q 应匹配所有测试", expression)
+		}
+	}
+
+	var filter *TagFilter
+	if !filter.Match([]string{"smoke"}) {
+		t.Error("nil 筛选条件应匹配所有测试")
+	}
+}
+
+func TestTagFilterOperators(t *testing.T) {
+	filter := mustParseTags(t, " smoke && !slow ")
+	if filter.String() != "smoke && !slow" {
+		t.Errorf("String() = %q，应去掉首尾空白", filter.String())
+	}
+	if !filter.Match([]string{"smoke", "login"}) {
+		t.Error("smoke && !slow 应匹配 [smoke login]")
+	}
+	if filter.Match([]string{"smoke", "slow"}) {
+		t.Error("smoke && !slow 不应匹配 [smoke slow]")
+	}
+
+	filter = mustParseTags(t, "negative||a11y")
+	if !filter.Match([]string{"a11y"}) || filter.Match([]string{"smoke"}) {
+		t.Error("没有空格的 || 表达式解析错误")
+	}
+
+	// 标签名可以包含字母、数字和 - _ : .
+	filter = mustParseTags(t, "browser:firefox && p1.high-risk_case && 登录")
+	if !filter.Match([]string{"browser:firefox", "p1.high-risk_case", "登录"}) {
+		t.Error("标签名中的特殊字符解析错误")
+	}
+}
+
+func TestTagFilterPrecedence(t *testing.T) {
+	// 优先级从高到低为 !、&&、||，即 smoke || (login && (!slow))
+	filter := mustParseTags(t, "smoke || login && !slow")
+	for _, tags := range [][]string{{"smoke"}, {"smoke", "slow"}, {"login"}} {
+		if !filter.Match(tags) {
+			t.Errorf("smoke || login && !slow 应匹配 %v", tags)
+		}
+	}
+	for _, tags := range [][]string{{"login", "slow"}, {"slow"}, nil} {
+		if filter.Match(tags) {
+			t.Errorf("smoke || login && !slow 不应匹配 %v", tags)
+		}
+	}
+
+	// 括号改变优先级
+	filter = mustParseTags(t, "(smoke || login) && !slow")
+	if filter.Match([]string{"smoke", "slow"}) {
+		t.Error("(smoke || login) && !slow 不应匹配 [smoke slow]")
+	}
+	filter = mustParseTags(t, "!(smoke || login)")
+	if filter.Match([]string{"login"}) || !filter.Match([]string{"slow"}) {
+		t.Error("!(smoke || login) 解析错误")
+	}
+	if !mustParseTags(t, "!!smoke").Match([]string{"smoke"}) {
+		t.Error("!!smoke 应匹配 [smoke]")
+	}
+}
+
+func TestTagFilterNames(t *testing.T) {
+	filter := mustParseTags(t, "(visual || smoke) && !slow")
+	for _, tags := range [][]string{{"visual"}, {"slow"}, {"a11y", "smoke"}} {
+		if !filter.Names(tags) {
+			t.Errorf("表达式写出了 %v 中的标签", tags)
+		}
+	}
+	if filter.Names([]string{"a11y", "login"}) {
+		t.Error("表达式没有写出 a11y 和 login")
+	}
+
+	// 空表达式不写出任何标签，但匹配所有测试
+	empty := mustParseTags(t, "")
+	if empty.Names([]string{"visual"}) {
+		t.Error("空表达式不应写出任何标签")
+	}
+	var none *TagFilter
+	if none.Names([]string{"visual"}) {
+		t.Error("nil 筛选条件不应写出任何标签")
+	}
+}
+
+func TestParseTagFilterErrors(t *testing.T) {
+	errors := map[string]string{
+		"smoke &&":         "表达式不完整",
+		"!":                "表达式不完整",
+		"(smoke || slow":   "缺少右括号",
+		"smoke)":           `多余的 ")"`,
+		"smoke slow":       `多余的 "slow"`,
+		"smoke & slow":     `多余的 "&"`,
+		"smoke || || slow": `意外的 "||"`,
+		"smoke && $":       `意外的 "$"`,
+		"()":               `意外的 ")"`,
+	}
+	for expression, message := range errors {
+		_, err := ParseTagFilter(expression)
+		if err == nil {
+			t.Errorf("ParseTagFilter(%q) 应返回错误", expression)
+			continue
+		}
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("ParseTagFilter(%q) = %q，应包含 %q", expression, err, message)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError 运行、测试或步骤超时，作为context的取消原因
+type TimeoutError struct {
+	Scope   string        // 超时的范围，如 测试「登录测试」
+	Timeout time.Duration // 配置的超时时长
+}
+
+// Error 返回超时说明
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s超时（%s）", e.Scope, e.Timeout)
+}
+
+// IsTimeout 判断错误是否为超时
+func IsTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// WithTimeout 返回在timeout后以TimeoutError取消的context，timeout不大于0时不限制
+func WithTimeout(parent context.Context, timeout time.Duration, scope string) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, timeout, &TimeoutError{Scope: scope, Timeout: timeout})
+}
+
+// RunWithContext 在单独的goroutine中执行fn，ctx先结束时立即返回取消原因，ctx已结束时不执行fn
+// 返回后fn可能仍在执行，调用方需要关闭页面或浏览器上下文使其尽快结束，并通过done等待
+func RunWithContext(ctx context.Context, fn func() bool) (bool, <-chan struct{}, error) {
+	finished := make(chan struct{})
+	if ctx.Err() != nil {
+		close(finished)
+		return false, finished, context.Cause(ctx)
+	}
+
+	var result bool
+	go func() {
+		defer close(finished)
+		result = fn()
+	}()
+
+	select {
+	case <-finished:
+		return result, finished, nil
+	case <-ctx.Done():
+		return false, finished, context.Cause(ctx)
+	}
+}
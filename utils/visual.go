@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/wan/playwright-go-demo/config"
+)
+
+// VisualResult 一次视觉对比的结果
+type VisualResult struct {
+	Name            string  // 截图名称
+	Expected        string  // 基线截图路径
+	Actual          string  // 本次截图路径
+	Diff            string  // 差异图路径，没有进行像素对比时为空
+	DiffPixels      int     // 差异像素数
+	TotalPixels     int     // 参与对比的像素数（不含忽略区域）
+	DiffRatio       float64 // 差异像素比例
+	Passed          bool    // 是否通过
+	BaselineCreated bool    // 更新基线模式下基线不存在，本次截图已保存为基线
+	BaselineUpdated bool    // 更新基线模式下已覆盖基线
+	Message         string  // 结果说明
+}
+
+// DiffPercent 返回以百分比表示的差异比例，便于报告展示
+func (v VisualResult) DiffPercent() string {
+	return fmt.Sprintf("%.3f%%", v.DiffRatio*100)
+}
+
+// VisualComparer 将截图与按浏览器保存的基线进行像素对比
+type VisualComparer struct {
+	config      config.VisualConfig
+	browserName string
+}
+
+// NewVisualComparer 创建一个新的视觉对比器
+func NewVisualComparer(visualConfig config.VisualConfig, browserName string) *VisualComparer {
+	if visualConfig.BaselineDir == "" {
+		visualConfig.BaselineDir = "./baselines"
+	}
+	if visualConfig.OutputDir == "" {
+		visualConfig.OutputDir = "./visual"
+	}
+	return &VisualComparer{
+		config:      visualConfig,
+		browserName: browserName,
+	}
+}
+
+// ComparePage 对整个页面截图并与基线对比，masks为额外的忽略区域
+func (v *VisualComparer) ComparePage(page playwright.Page, name string, masks ...config.Region) (*VisualResult, error) {
+	actualPath, err := v.prepareActualPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := TakeScreenshot(page, actualPath); err != nil {
+		return nil, err
+	}
+	return v.compare(name, actualPath, masks)
+}
+
+// CompareElement 对指定元素截图并与基线对比，masks为相对元素左上角的忽略区域
+func (v *VisualComparer) CompareElement(page playwright.Page, selector string, name string, masks ...config.Region) (*VisualResult, error) {
+	actualPath, err := v.prepareActualPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := TakeElementScreenshot(page, selector, actualPath); err != nil {
+		return nil, err
+	}
+	return v.compare(name, actualPath, masks)
+}
+
+// prepareActualPath 返回本次截图的保存路径并确保目录存在
+func (v *VisualComparer) prepareActualPath(name string) (string, error) {
+	dir := BrowserDir(v.config.OutputDir, v.browserName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("无法创建视觉对比输出目录: %w", err)
+	}
+	return filepath.Join(dir, sanitizeFileName(name)+"-actual.png"), nil
+}
+
+// compare 将本次截图与基线对比
+func (v *VisualComparer) compare(name, actualPath string, masks []config.Region) (*VisualResult, error) {
+	fileName := sanitizeFileName(name)
+	baselinePath := filepath.Join(BrowserDir(v.config.BaselineDir, v.browserName), fileName+".png")
+	result := &VisualResult{
+		Name:     name,
+		Expected: baselinePath,
+		Actual:   actualPath,
+	}
+
+	// 更新基线模式下直接保存本次截图为基线；否则基线不存在时对比失败，避免没有基线时总是通过
+	_, statErr := os.Stat(baselinePath)
+	if v.config.UpdateBaselines {
+		if err := copyFile(actualPath, baselinePath); err != nil {
+			return nil, fmt.Errorf("保存基线截图失败: %w", err)
+		}
+		result.Passed = true
+		if statErr == nil {
+			result.BaselineUpdated = true
+			result.Message = "已使用本次截图更新基线"
+		} else {
+			result.BaselineCreated = true
+			result.Message = "基线不存在，已将本次截图保存为基线"
+		}
+		return result, nil
+	}
+	if os.IsNotExist(statErr) {
+		result.Expected = ""
+		result.Message = fmt.Sprintf("基线 %s 不存在，本次截图已写入 %s，确认无误后使用 -update-baselines 生成基线", baselinePath, actualPath)
+		return result, nil
+	}
+
+	expected, err := readPNG(baselinePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取基线截图失败: %w", err)
+	}
+	actual, err := readPNG(actualPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取本次截图失败: %w", err)
+	}
+
+	allMasks := append(append([]config.Region(nil), v.config.Masks[name]...), masks...)
+	diff, diffPixels, totalPixels := diffImages(expected, actual, allMasks, v.config.Threshold)
+
+	result.Diff = filepath.Join(filepath.Dir(actualPath), fileName+"-diff.png")
+	if err := writePNG(result.Diff, diff); err != nil {
+		return nil, fmt.Errorf("保存差异图失败: %w", err)
+	}
+
+	result.DiffPixels = diffPixels
+	result.TotalPixels = totalPixels
+	if totalPixels > 0 {
+		result.DiffRatio = float64(diffPixels) / float64(totalPixels)
+	}
+	if expected.Bounds().Size() != actual.Bounds().Size() {
+		result.Message = fmt.Sprintf("截图尺寸不同: 基线 %v，本次 %v", expected.Bounds().Size(), actual.Bounds().Size())
+		return result, nil
+	}
+
+	result.Passed = result.DiffRatio <= v.config.MaxDiffRatio
+	result.Message = fmt.Sprintf("差异像素 %d/%d（%s），允许 %.3f%%", diffPixels, totalPixels, result.DiffPercent(), v.config.MaxDiffRatio*100)
+	return result, nil
+}
+
+// diffImages 逐像素对比两张图片，返回差异图、差异像素数和参与对比的像素数
+func diffImages(expected, actual image.Image, masks []config.Region, threshold float64) (*image.RGBA, int, int) {
+	expectedBounds := expected.Bounds()
+	actualBounds := actual.Bounds()
+	width := max(expectedBounds.Dx(), actualBounds.Dx())
+	height := max(expectedBounds.Dy(), actualBounds.Dy())
+
+	diff := image.NewRGBA(image.Rect(0, 0, width, height))
+	diffColor := color.RGBA{R: 255, A: 255}
+	maskColor := color.RGBA{R: 120, G: 160, B: 255, A: 255}
+
+	diffPixels := 0
+	totalPixels := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if inRegions(x, y, masks) {
+				diff.Set(x, y, maskColor)
+				continue
+			}
+			totalPixels++
+
+			inExpected := x < expectedBounds.Dx() && y < expectedBounds.Dy()
+			inActual := x < actualBounds.Dx() && y < actualBounds.Dy()
+			if !inExpected || !inActual {
+				// 尺寸不同时超出部分都算作差异
+				diffPixels++
+				diff.Set(x, y, diffColor)
+				continue
+			}
+
+			expectedPixel := expected.At(expectedBounds.Min.X+x, expectedBounds.Min.Y+y)
+			actualPixel := actual.At(actualBounds.Min.X+x, actualBounds.Min.Y+y)
+			if colorDistance(expectedPixel, actualPixel) > threshold {
+				diffPixels++
+				diff.Set(x, y, diffColor)
+				continue
+			}
+
+			// 相同的像素以淡化的灰度显示，便于定位差异
+			gray := color.GrayModel.Convert(actualPixel).(color.Gray)
+			faded := 255 - (255-gray.Y)/4
+			diff.Set(x, y, color.RGBA{R: faded, G: faded, B: faded, A: 255})
+		}
+	}
+
+	return diff, diffPixels, totalPixels
+}
+
+// colorDistance 计算两个颜色的归一化距离(0-1)
+func colorDistance(a, b color.Color) float64 {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	dr := float64(ar) - float64(br)
+	dg := float64(ag) - float64(bg)
+	db := float64(ab) - float64(bb)
+	da := float64(aa) - float64(ba)
+	return math.Sqrt(dr*dr+dg*dg+db*db+da*da) / (2 * 0xffff)
+}
+
+// inRegions 判断像素是否位于任一区域内
+func inRegions(x, y int, regions []config.Region) bool {
+	for _, region := range regions {
+		if x >= region.X && x < region.X+region.Width && y >= region.Y && y < region.Y+region.Height {
+			return true
+		}
+	}
+	return false
+}
+
+// readPNG 读取PNG图片
+func readPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+// writePNG 保存PNG图片
+func writePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+// copyFile 复制文件，自动创建目标目录
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
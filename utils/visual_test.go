@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/wan/playwright-go-demo/config"
+)
+
+var (
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	black = color.RGBA{A: 255}
+)
+
+// filled 创建 width x height 的白色图片，并将 dark 区域涂黑
+func filled(width, height int, dark ...image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+	for _, rect := range dark {
+		draw.Draw(img, rect, image.NewUniform(black), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func TestDiffImagesCountsChangedPixels(t *testing.T) {
+	expected := filled(10, 10)
+	actual := filled(10, 10, image.Rect(8, 8, 10, 10))
+
+	diff, diffPixels, totalPixels := diffImages(expected, actual, nil, 0.1)
+	if diffPixels != 4 || totalPixels != 100 {
+		t.Fatalf("差异像素 %d/%d，期望 4/100", diffPixels, totalPixels)
+	}
+	if diff.RGBAAt(9, 9) != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("差异像素应标为红色，实际 %v", diff.RGBAAt(9, 9))
+	}
+	if diff.RGBAAt(0, 0) == (color.RGBA{R: 255, A: 255}) {
+		t.Error("相同的像素不应标为红色")
+	}
+}
+
+func TestDiffImagesMasks(t *testing.T) {
+	expected := filled(10, 10)
+	actual := filled(10, 10, image.Rect(8, 8, 10, 10))
+
+	// 遮罩完全覆盖差异区域，遮罩内的像素不参与对比
+	_, diffPixels, totalPixels := diffImages(expected, actual, []config.Region{{X: 8, Y: 8, Width: 2, Height: 2}}, 0.1)
+	if diffPixels != 0 || totalPixels != 96 {
+		t.Errorf("完全遮罩: 差异像素 %d/%d，期望 0/96", diffPixels, totalPixels)
+	}
+
+	// 遮罩只覆盖最右一列，差异区域中剩下 (8,8) 和 (8,9)
+	masks := []config.Region{{X: 9, Y: 0, Width: 1, Height: 10}, {X: 0, Y: 0, Width: 3, Height: 3}}
+	diff, diffPixels, totalPixels := diffImages(expected, actual, masks, 0.1)
+	if diffPixels != 2 || totalPixels != 81 {
+		t.Errorf("部分遮罩: 差异像素 %d/%d，期望 2/81", diffPixels, totalPixels)
+	}
+	if diff.RGBAAt(1, 1) != (color.RGBA{R: 120, G: 160, B: 255, A: 255}) {
+		t.Errorf("遮罩区域应以蓝色显示，实际 %v", diff.RGBAAt(1, 1))
+	}
+}
+
+func TestDiffImagesSizeMismatch(t *testing.T) {
+	// 实际截图多出两行，多出的像素都算作差异
+	diff, diffPixels, totalPixels := diffImages(filled(10, 10), filled(10, 12), nil, 0.1)
+	if diffPixels != 20 || totalPixels != 120 {
+		t.Errorf("差异像素 %d/%d，期望 20/120", diffPixels, totalPixels)
+	}
+	if diff.Bounds() != image.Rect(0, 0, 10, 12) {
+		t.Errorf("差异图尺寸 %v，应取两张图片的最大尺寸", diff.Bounds())
+	}
+}
+
+func TestColorDistanceThreshold(t *testing.T) {
+	if d := colorDistance(white, white); d != 0 {
+		t.Errorf("相同颜色的距离为 %v", d)
+	}
+	if d := colorDistance(white, black); d <= 0.5 || d > 1 {
+		t.Errorf("黑白两色的距离为 %v，应接近 1", d)
+	}
+
+	expected := image.NewUniform(color.RGBA{R: 200, G: 200, B: 200, A: 255})
+	actual := image.NewUniform(color.RGBA{R: 205, G: 200, B: 200, A: 255})
+	bounds := image.Rect(0, 0, 4, 4)
+	expectedImg, actualImg := image.NewRGBA(bounds), image.NewRGBA(bounds)
+	draw.Draw(expectedImg, bounds, expected, image.Point{}, draw.Src)
+	draw.Draw(actualImg, bounds, actual, image.Point{}, draw.Src)
+	if _, diffPixels, _ := diffImages(expectedImg, actualImg, nil, 0.1); diffPixels != 0 {
+		t.Errorf("颜色差异低于阈值时差异像素为 %d", diffPixels)
+	}
+	if _, diffPixels, _ := diffImages(expectedImg, actualImg, nil, 0); diffPixels != 16 {
+		t.Errorf("阈值为 0 时差异像素为 %d，期望 16", diffPixels)
+	}
+}